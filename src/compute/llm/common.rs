@@ -1,8 +1,12 @@
-use langchain_rust::language_models::llm::LLM;
+use async_openai::error::OpenAIError;
+use langchain_rust::language_models::{llm::LLM, LLMError};
+use std::env;
+use std::time::Duration;
 use tokio_util::sync::CancellationToken;
 
 use super::ollama::create_ollama;
-use super::openai::create_openai;
+use super::openai::{create_openai, openai_api_keys};
+use crate::config::constants::*;
 
 #[derive(Debug, Default)]
 pub enum ModelProvider {
@@ -33,29 +37,207 @@ impl From<&ModelProvider> for String {
     }
 }
 
+impl ModelProvider {
+    /// Returns the timeout for a single generation with this provider, read from
+    /// `OLLAMA_TIMEOUT` or `OPENAI_TIMEOUT` in seconds.
+    ///
+    /// Returns `None` if the timeout is not set or is invalid.
+    pub fn timeout(&self) -> Option<Duration> {
+        let var_name = match self {
+            ModelProvider::Ollama => OLLAMA_TIMEOUT,
+            ModelProvider::OpenAI => OPENAI_TIMEOUT,
+        };
+
+        let timeout_str = env::var(var_name).ok().filter(|s| !s.is_empty())?;
+        match timeout_str.parse::<u64>() {
+            Ok(secs) if secs > 0 => Some(Duration::from_secs(secs)),
+            _ => {
+                log::warn!("Invalid {}: {}, ignoring.", var_name, timeout_str);
+                None
+            }
+        }
+    }
+}
+
 impl std::fmt::Display for ModelProvider {
     fn fmt(&self, f: &mut std::fmt::Formatter<'_>) -> std::fmt::Result {
         write!(f, "{}", String::from(self))
     }
 }
 
-/// Creates an LLM of the given type, which is a LangChain object.
+/// Creates the LLMs of the given type, which are LangChain objects.
 ///
 /// The respective setups of the LLMs are done within this function,
 /// e.g. Ollama will pull the model if it does not exist locally.
-pub async fn create_llm(
+///
+/// OpenAI returns a client for each of its API keys, in the order they should be tried.
+pub async fn create_llms(
     llm: ModelProvider,
     model: String,
     cancellation: CancellationToken,
-) -> Result<Box<dyn LLM>, String> {
+) -> Result<Vec<Box<dyn LLM>>, String> {
     match llm {
         ModelProvider::Ollama => {
             let client = create_ollama(cancellation, model).await?;
-            Ok(Box::new(client))
+            Ok(vec![Box::new(client)])
         }
         ModelProvider::OpenAI => {
-            let client = create_openai(model);
-            Ok(Box::new(client))
+            let api_keys = openai_api_keys();
+            if api_keys.is_empty() {
+                return Ok(vec![Box::new(create_openai(model, None))]);
+            }
+
+            Ok(api_keys
+                .into_iter()
+                .map(|api_key| {
+                    Box::new(create_openai(model.clone(), Some(api_key))) as Box<dyn LLM>
+                })
+                .collect())
         }
     }
 }
+
+/// Invokes the LLM with the given prompt, failing if the result does not arrive within `timeout`.
+pub async fn invoke_llm(
+    llm: &dyn LLM,
+    prompt: &str,
+    timeout: Option<Duration>,
+) -> Result<String, LLMError> {
+    match timeout {
+        Some(timeout) => match tokio::time::timeout(timeout, llm.invoke(prompt)).await {
+            Ok(result) => result,
+            Err(_) => Err(LLMError::OtherError(format!(
+                "Timed out after {} seconds.",
+                timeout.as_secs()
+            ))),
+        },
+        None => llm.invoke(prompt).await,
+    }
+}
+
+/// OpenAI error codes and types that are caused by the API key, see <https://platform.openai.com/docs/guides/error-codes>.
+const API_KEY_ERROR_CODES: [&str; 3] = [
+    "invalid_api_key",
+    "rate_limit_exceeded",
+    "insufficient_quota",
+];
+
+/// Returns whether the given LLM error is caused by the API key, that is an authentication,
+/// rate-limit or quota error, in which case trying another API key may help.
+///
+/// The error is classified by the error code returned by OpenAI, or by the HTTP status of the request.
+pub fn is_api_key_error(error: &LLMError) -> bool {
+    match error {
+        LLMError::OpenAIError(OpenAIError::ApiError(api_error)) => {
+            let code = api_error.code.as_ref().and_then(|code| code.as_str());
+            let r#type = api_error.r#type.as_deref();
+            [code, r#type]
+                .into_iter()
+                .flatten()
+                .any(|code| API_KEY_ERROR_CODES.contains(&code))
+        }
+        LLMError::OpenAIError(OpenAIError::Reqwest(e)) => {
+            matches!(e.status().map(|status| status.as_u16()), Some(401 | 429))
+        }
+        _ => false,
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use async_openai::error::ApiError;
+    use async_trait::async_trait;
+    use futures::Stream;
+    use langchain_rust::{
+        language_models::GenerateResult,
+        schemas::{Message, StreamData},
+    };
+    use serde_json::json;
+    use std::pin::Pin;
+
+    /// An LLM that answers after sleeping for the given duration.
+    #[derive(Clone)]
+    struct SleepyLLM(Duration);
+
+    #[async_trait]
+    impl LLM for SleepyLLM {
+        async fn generate(&self, _messages: &[Message]) -> Result<GenerateResult, LLMError> {
+            tokio::time::sleep(self.0).await;
+            Ok(GenerateResult {
+                generation: "done".to_string(),
+                ..Default::default()
+            })
+        }
+
+        async fn stream(
+            &self,
+            _messages: &[Message],
+        ) -> Result<Pin<Box<dyn Stream<Item = Result<StreamData, LLMError>> + Send>>, LLMError>
+        {
+            Err(LLMError::OtherError(
+                "Streaming is not supported.".to_string(),
+            ))
+        }
+    }
+
+    #[test]
+    fn test_provider_timeout() {
+        env::set_var(OLLAMA_TIMEOUT, "30");
+        env::set_var(OPENAI_TIMEOUT, "soon");
+
+        assert_eq!(
+            ModelProvider::Ollama.timeout(),
+            Some(Duration::from_secs(30))
+        );
+        assert_eq!(ModelProvider::OpenAI.timeout(), None);
+
+        env::remove_var(OLLAMA_TIMEOUT);
+        env::remove_var(OPENAI_TIMEOUT);
+    }
+
+    /// An OpenAI API error with the given type and code.
+    fn api_error(r#type: &str, code: Option<&str>) -> LLMError {
+        LLMError::OpenAIError(OpenAIError::ApiError(ApiError {
+            message: "Request failed after 401 retries.".to_string(),
+            r#type: Some(r#type.to_string()),
+            param: None,
+            code: code.map(|code| json!(code)),
+        }))
+    }
+
+    #[test]
+    fn test_is_api_key_error() {
+        assert!(is_api_key_error(&api_error(
+            "invalid_request_error",
+            Some("invalid_api_key")
+        )));
+        assert!(is_api_key_error(&api_error(
+            "requests",
+            Some("rate_limit_exceeded")
+        )));
+        assert!(is_api_key_error(&api_error("insufficient_quota", None)));
+
+        // messages are not classified, only the codes are
+        assert!(!is_api_key_error(&api_error(
+            "invalid_request_error",
+            Some("context_length_exceeded")
+        )));
+        assert!(!is_api_key_error(&LLMError::OtherError(
+            "Timed out after 401 seconds.".to_string()
+        )));
+    }
+
+    #[tokio::test]
+    async fn test_invoke_llm_timeout() {
+        let llm = SleepyLLM(Duration::from_millis(1500));
+
+        let result = invoke_llm(&llm, "prompt", Some(Duration::from_secs(1))).await;
+        assert!(
+            matches!(result, Err(LLMError::OtherError(e)) if e == "Timed out after 1 seconds.")
+        );
+
+        let result = invoke_llm(&llm, "prompt", Some(Duration::from_secs(2))).await;
+        assert_eq!(result.unwrap(), "done");
+    }
+}
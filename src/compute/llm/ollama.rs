@@ -1,9 +1,10 @@
 use std::env;
 use std::sync::Arc;
+use std::time::Instant;
 use tokio_util::sync::CancellationToken;
 
 use langchain_rust::llm::client::Ollama as OllamaLang;
-use ollama_rs::Ollama;
+use ollama_rs::{generation::completion::request::GenerationRequest, Ollama};
 
 use crate::config::constants::*;
 
@@ -16,7 +17,10 @@ pub async fn create_ollama(
     log::info!("Ollama URL: {}", client.uri());
     log::info!("Ollama Model: {}", model);
 
-    pull_model(&client, &model, cancellation).await?;
+    pull_model(&client, &model, cancellation.clone()).await?;
+    if should_warm_up() {
+        warm_up_model(&client, &model, cancellation).await;
+    }
 
     Ok(OllamaLang::new(Arc::new(client), model, None))
 }
@@ -94,6 +98,58 @@ pub async fn pull_model(
     Ok(())
 }
 
+/// Returns whether the model should be warmed-up at startup.
+///
+/// Warm-up is opt-in via `OLLAMA_WARM_UP=true`, and it is skipped when `OLLAMA_KEEP_ALIVE` is zero
+/// because Ollama would unload the model right after loading it.
+fn should_warm_up() -> bool {
+    let warm_up = env::var(OLLAMA_WARM_UP)
+        .map(|value| value.trim().eq_ignore_ascii_case("true"))
+        .unwrap_or(false);
+    if !warm_up {
+        return false;
+    }
+
+    // keep-alive is a duration such as "0", "0s" or "5m"
+    let keep_alive_is_zero = env::var(OLLAMA_KEEP_ALIVE)
+        .map(|value| {
+            value
+                .trim()
+                .trim_end_matches(char::is_alphabetic)
+                .parse::<f64>()
+                .map(|duration| duration == 0.0)
+                .unwrap_or(false)
+        })
+        .unwrap_or(false);
+    if keep_alive_is_zero {
+        log::info!("Skipping model warm-up, {} is zero.", OLLAMA_KEEP_ALIVE);
+        return false;
+    }
+
+    true
+}
+
+/// Loads the model into memory by sending an empty prompt, so that the first task
+/// does not have to wait for the model to be loaded.
+///
+/// The model stays in memory for `OLLAMA_KEEP_ALIVE` duration, which is handled by Ollama itself.
+/// Failing to warm-up is not an error, the model will be loaded on the first task anyways.
+pub async fn warm_up_model(client: &Ollama, model: &str, cancellation: CancellationToken) {
+    log::info!("Warming up model: {}", model);
+    let request = GenerationRequest::new(model.to_string(), String::new());
+    let start = Instant::now();
+
+    tokio::select! {
+        _ = cancellation.cancelled() => {}
+        result = client.generate(request) => {
+            match result {
+                Ok(_) => log::info!("Model {} warmed-up in {:?}.", model, start.elapsed()),
+                Err(e) => log::warn!("Could not warm-up model {}: {:?}", model, e),
+            }
+        }
+    }
+}
+
 #[cfg(test)]
 mod tests {
     use super::*;
@@ -107,4 +163,26 @@ mod tests {
         let ollama = create_ollama_client();
         assert_eq!(ollama.uri(), "http://im-a-host:11434");
     }
+
+    #[test]
+    fn test_should_warm_up() {
+        env::remove_var(OLLAMA_WARM_UP);
+        env::remove_var(OLLAMA_KEEP_ALIVE);
+        assert!(!should_warm_up());
+
+        env::set_var(OLLAMA_WARM_UP, "true");
+        assert!(should_warm_up());
+
+        env::set_var(OLLAMA_KEEP_ALIVE, "5m");
+        assert!(should_warm_up());
+
+        env::set_var(OLLAMA_KEEP_ALIVE, "0");
+        assert!(!should_warm_up());
+
+        env::set_var(OLLAMA_KEEP_ALIVE, "0s");
+        assert!(!should_warm_up());
+
+        env::remove_var(OLLAMA_WARM_UP);
+        env::remove_var(OLLAMA_KEEP_ALIVE);
+    }
 }
@@ -5,12 +5,12 @@ use langchain_rust::llm::OpenAIConfig;
 
 use crate::config::constants::*;
 
-/// Creates an OpenAI langchain client.
+/// Creates an OpenAI langchain client with the given API key.
 ///
 /// Will check for the following environment variables:
 ///
 /// - `OPENAI_API_BASE`
-/// - `OPENAI_API_KEY`
+/// - `OPENAI_API_KEY`, if `api_key` is `None`
 /// - `OPENAI_ORG_ID`
 /// - `OPENAI_PROJECT_ID`
 ///
@@ -19,13 +19,13 @@ use crate::config::constants::*;
 /// ```rs
 /// fdsjkjfds
 /// ```
-pub fn create_openai(model: String) -> OpenAI<OpenAIConfig> {
+pub fn create_openai(model: String, api_key: Option<String>) -> OpenAI<OpenAIConfig> {
     let mut config = OpenAIConfig::default();
 
     if let Ok(api_base) = env::var(OPENAI_API_BASE_URL) {
         config = config.with_api_base(api_base);
     }
-    if let Ok(api_key) = env::var(OPENAI_API_KEY) {
+    if let Some(api_key) = api_key.or_else(|| env::var(OPENAI_API_KEY).ok()) {
         config = config.with_api_key(api_key);
     }
     if let Ok(org_id) = env::var(OPENAI_ORG_ID) {
@@ -38,18 +38,46 @@ pub fn create_openai(model: String) -> OpenAI<OpenAIConfig> {
     OpenAI::new(config).with_model(model)
 }
 
+/// Returns the OpenAI API keys in the order they should be tried, that is
+/// `OPENAI_API_KEY` followed by the comma-separated `OPENAI_FALLBACK_API_KEYS`.
+///
+/// Empty keys are ignored.
+pub fn openai_api_keys() -> Vec<String> {
+    let api_key = env::var(OPENAI_API_KEY).unwrap_or_default();
+    let fallback_api_keys = env::var(OPENAI_FALLBACK_API_KEYS).unwrap_or_default();
+
+    std::iter::once(api_key.as_str())
+        .chain(fallback_api_keys.split(','))
+        .map(|key| key.trim())
+        .filter(|key| !key.is_empty())
+        .map(|key| key.to_string())
+        .collect()
+}
+
 #[cfg(test)]
 mod tests {
     use super::*;
     use langchain_rust::language_models::llm::LLM;
 
+    #[test]
+    fn test_openai_api_keys() {
+        env::set_var(OPENAI_API_KEY, "key-1");
+        env::set_var(OPENAI_FALLBACK_API_KEYS, "key-2, ,key-3");
+        assert_eq!(openai_api_keys(), vec!["key-1", "key-2", "key-3"]);
+
+        env::remove_var(OPENAI_FALLBACK_API_KEYS);
+        assert_eq!(openai_api_keys(), vec!["key-1"]);
+
+        env::remove_var(OPENAI_API_KEY);
+    }
+
     #[tokio::test]
     #[ignore] // cargo test --package dkn-compute --lib --all-features -- compute::openai::tests::test_openai --exact --show-output --ignored
     async fn test_openai() {
         let value = "FOOBARFOOBAR"; // use with your own key, with caution
         env::set_var(OPENAI_API_KEY, value);
 
-        let openai = create_openai("gpt-3.5-turbo".to_string());
+        let openai = create_openai("gpt-3.5-turbo".to_string(), None);
 
         let prompt = "Once upon a time, in a land far away, there was a dragon.";
         let response = openai
@@ -1,3 +1,4 @@
 pub mod llm;
 pub mod payload;
+pub mod queue;
 pub mod search_python;
@@ -60,6 +60,7 @@ impl<T> TaskRequestPayload<T> {
 #[derive(Debug, Clone)]
 pub struct TaskRequest<T> {
     pub task_id: String,
+    pub(crate) deadline: u128,
     pub(crate) input: T,
     pub(crate) public_key: Vec<u8>,
 }
@@ -0,0 +1,93 @@
+use crate::{compute::payload::TaskRequest, utils::get_current_time_nanos};
+
+/// A queue of tasks that are assigned to this node but not yet processed, ordered by their deadlines.
+///
+/// Tasks are drained from Waku when they are read, so a worker keeps the ones that do not fit in the
+/// current batch here, and processes them in the next batches.
+#[derive(Debug)]
+pub struct TaskQueue<T> {
+    tasks: Vec<TaskRequest<T>>,
+}
+
+impl<T> Default for TaskQueue<T> {
+    fn default() -> Self {
+        Self::new()
+    }
+}
+
+impl<T> TaskQueue<T> {
+    pub fn new() -> Self {
+        Self { tasks: Vec::new() }
+    }
+
+    /// Adds the given tasks to the queue, keeping the queue sorted by deadline.
+    pub fn push(&mut self, tasks: Vec<TaskRequest<T>>) {
+        self.tasks.extend(tasks);
+        self.tasks.sort_by(|a, b| a.deadline.cmp(&b.deadline));
+    }
+
+    /// Removes the tasks with past deadlines, and returns the next batch of tasks with the earliest deadlines.
+    ///
+    /// If `max_batch_size` is `None`, all tasks are returned.
+    pub fn next_batch(&mut self, max_batch_size: Option<usize>) -> Vec<TaskRequest<T>> {
+        let now = get_current_time_nanos();
+        self.tasks.retain(|task| {
+            if now >= task.deadline {
+                log::debug!("Skipping {} due to deadline.", task.task_id);
+                return false;
+            }
+            true
+        });
+
+        let batch_size = max_batch_size
+            .unwrap_or(self.tasks.len())
+            .min(self.tasks.len());
+        self.tasks.drain(..batch_size).collect()
+    }
+
+    pub fn len(&self) -> usize {
+        self.tasks.len()
+    }
+
+    pub fn is_empty(&self) -> bool {
+        self.tasks.is_empty()
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn task(task_id: &str, deadline: u128) -> TaskRequest<String> {
+        TaskRequest {
+            task_id: task_id.to_string(),
+            deadline,
+            input: String::new(),
+            public_key: Vec::new(),
+        }
+    }
+
+    #[test]
+    fn test_task_queue() {
+        let now = get_current_time_nanos();
+        let mut queue = TaskQueue::new();
+        queue.push(vec![
+            task("late", now + 3_000_000_000),
+            task("expired", now - 1),
+            task("early", now + 1_000_000_000),
+        ]);
+        queue.push(vec![task("middle", now + 2_000_000_000)]);
+        assert_eq!(queue.len(), 4);
+
+        // expired task is pruned, earliest deadline comes first
+        let batch = queue.next_batch(Some(2));
+        assert_eq!(batch[0].task_id, "early");
+        assert_eq!(batch[1].task_id, "middle");
+
+        // overflow is kept for the next batch
+        assert_eq!(queue.len(), 1);
+        let batch = queue.next_batch(None);
+        assert_eq!(batch[0].task_id, "late");
+        assert!(queue.is_empty());
+    }
+}
@@ -3,9 +3,19 @@ use hex_literal::hex;
 //////////////////// DKN Compute Node ////////////////////
 pub const DKN_TASKS: &str = "DKN_TASKS";
 pub const DKN_ADMIN_PUBLIC_KEY: &str = "DKN_ADMIN_PUBLIC_KEY";
+/// Selects the Admin Node environment to trust, e.g. `staging` reads the keys from `DKN_ADMIN_PUBLIC_KEY_STAGING`.
+pub const DKN_ADMIN_ENV: &str = "DKN_ADMIN_ENV";
 pub const DKN_WALLET_SECRET_KEY: &str = "DKN_WALLET_SECRET_KEY";
 pub const DKN_WALLET_PUBLIC_KEY: &str = "DKN_WALLET_PUBLIC_KEY";
 pub const DKN_WALLET_ADDRESS: &str = "DKN_WALLET_ADDRESS";
+pub const DKN_MAX_BATCH_SIZE: &str = "DKN_MAX_BATCH_SIZE";
+/// Interval in milliseconds at which heartbeat messages are checked.
+pub const DKN_HEARTBEAT_INTERVAL: &str = "DKN_HEARTBEAT_INTERVAL";
+pub const DEFAULT_DKN_HEARTBEAT_INTERVAL: u64 = 1000;
+/// Upper bound for `DKN_HEARTBEAT_INTERVAL`, a local sanity limit rather than a network rule.
+pub const MAX_DKN_HEARTBEAT_INTERVAL: u64 = 10_000;
+/// Lower bound for `DKN_HEARTBEAT_INTERVAL`, a local sanity limit rather than a network rule.
+pub const MIN_DKN_HEARTBEAT_INTERVAL: u64 = 100;
 /// 33 byte compressed public key of secret key from hex(b"dria) * 8, dummy only
 pub const DEFAULT_DKN_ADMIN_PUBLIC_KEY: &[u8; 33] =
     &hex!("0208ef5e65a9c656a6f92fb2c770d5d5e2ecffe02a6aade19207f75110be6ae658");
@@ -30,9 +40,19 @@ pub const OLLAMA_HOST: &str = "OLLAMA_HOST";
 pub const OLLAMA_PORT: &str = "OLLAMA_PORT";
 pub const DEFAULT_OLLAMA_HOST: &str = "http://127.0.0.1";
 pub const DEFAULT_OLLAMA_PORT: u16 = 11434;
+/// Timeout in seconds for a single Ollama generation, no timeout if not set.
+pub const OLLAMA_TIMEOUT: &str = "OLLAMA_TIMEOUT";
+/// Loads the model into memory at startup when set to `true`.
+pub const OLLAMA_WARM_UP: &str = "OLLAMA_WARM_UP";
+/// Duration of the model's life in memory, handled by Ollama itself.
+pub const OLLAMA_KEEP_ALIVE: &str = "OLLAMA_KEEP_ALIVE";
 
 //////////////////// Provider: OpenAI ////////////////////
 pub const OPENAI_API_BASE_URL: &str = "OPENAI_API_BASE_URL";
 pub const OPENAI_API_KEY: &str = "OPENAI_API_KEY";
+/// Comma-separated API keys to fall back to when a request with `OPENAI_API_KEY` fails.
+pub const OPENAI_FALLBACK_API_KEYS: &str = "OPENAI_FALLBACK_API_KEYS";
 pub const OPENAI_ORG_ID: &str = "OPENAI_ORG_ID";
 pub const OPENAI_PROJECT_ID: &str = "OPENAI_PROJECT_ID";
+/// Timeout in seconds for a single OpenAI generation, no timeout if not set.
+pub const OPENAI_TIMEOUT: &str = "OPENAI_TIMEOUT";
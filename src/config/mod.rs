@@ -6,6 +6,7 @@ use constants::*;
 use ecies::PublicKey;
 use libsecp256k1::{PublicKeyFormat, SecretKey};
 use std::env;
+use std::time::Duration;
 
 #[allow(non_snake_case)]
 #[derive(Debug, Clone)]
@@ -16,8 +17,16 @@ pub struct DriaComputeNodeConfig {
     pub DKN_WALLET_PUBLIC_KEY: PublicKey,
     /// Wallet address, derived from the public key.
     pub DKN_WALLET_ADDRESS: [u8; 20],
-    /// Admin public key, used for message authenticity.
-    pub DKN_ADMIN_PUBLIC_KEY: PublicKey,
+    /// Admin public keys of the selected Admin environment, used for message authenticity.
+    ///
+    /// A message is authentic if it is signed by any of these keys, e.g. by
+    /// either the old or the new key of an Admin Node during a key rotation.
+    pub DKN_ADMIN_PUBLIC_KEYS: Vec<PublicKey>,
+    /// Maximum number of tasks to process in a batch, `None` means no limit.
+    /// Tasks that do not fit in a batch are processed in the next ones.
+    pub DKN_MAX_BATCH_SIZE: Option<usize>,
+    /// Interval at which heartbeat messages are checked.
+    pub DKN_HEARTBEAT_INTERVAL: Duration,
 }
 
 impl DriaComputeNodeConfig {
@@ -34,23 +43,69 @@ impl DriaComputeNodeConfig {
 
         let public_key = PublicKey::from_secret_key(&secret_key);
 
-        let admin_public_key = PublicKey::parse_slice(
-            hex::decode(env::var(DKN_ADMIN_PUBLIC_KEY).unwrap_or_default())
-                .unwrap_or_default()
-                .as_slice(),
-            Some(PublicKeyFormat::Compressed),
-        )
-        .unwrap_or(
-            PublicKey::parse_compressed(DEFAULT_DKN_ADMIN_PUBLIC_KEY)
-                .expect("Should decrypt default Admin public key."),
-        );
+        let admin_env = admin_env();
+        let mut admin_public_keys = parse_admin_public_keys(admin_env.as_deref());
+        if admin_public_keys.is_empty() {
+            // a selected environment must not fall back to the keys of another one
+            if let Some(admin_env) = &admin_env {
+                panic!(
+                    "Admin environment {} is selected, but {} has no valid public keys.",
+                    admin_env,
+                    admin_public_key_var(Some(admin_env))
+                );
+            }
+            admin_public_keys.push(
+                PublicKey::parse_compressed(DEFAULT_DKN_ADMIN_PUBLIC_KEY)
+                    .expect("Should decrypt default Admin public key."),
+            );
+        }
 
         let address = to_address(&public_key);
 
-        log::info!(
-            "Admin Public Key: 0x{}",
-            hex::encode(admin_public_key.serialize_compressed())
-        );
+        let max_batch_size = env::var(DKN_MAX_BATCH_SIZE)
+            .ok()
+            .filter(|size_str| !size_str.is_empty())
+            .and_then(|size_str| match size_str.parse::<usize>() {
+                Ok(0) => None, // no limit
+                Ok(size) => Some(size),
+                _ => {
+                    log::warn!("Invalid {}: {}, ignoring.", DKN_MAX_BATCH_SIZE, size_str);
+                    None
+                }
+            });
+
+        let heartbeat_interval = env::var(DKN_HEARTBEAT_INTERVAL)
+            .ok()
+            .filter(|interval_str| !interval_str.is_empty())
+            .and_then(|interval_str| match interval_str.parse::<u64>() {
+                Ok(interval)
+                    if (MIN_DKN_HEARTBEAT_INTERVAL..=MAX_DKN_HEARTBEAT_INTERVAL)
+                        .contains(&interval) =>
+                {
+                    Some(interval)
+                }
+                _ => {
+                    log::warn!(
+                        "Invalid {}: {}, expected milliseconds between {} and {}, using default.",
+                        DKN_HEARTBEAT_INTERVAL,
+                        interval_str,
+                        MIN_DKN_HEARTBEAT_INTERVAL,
+                        MAX_DKN_HEARTBEAT_INTERVAL
+                    );
+                    None
+                }
+            })
+            .unwrap_or(DEFAULT_DKN_HEARTBEAT_INTERVAL);
+
+        if let Some(admin_env) = &admin_env {
+            log::info!("Admin Environment: {}", admin_env);
+        }
+        for admin_public_key in &admin_public_keys {
+            log::info!(
+                "Admin Public Key: 0x{}",
+                hex::encode(admin_public_key.serialize_compressed())
+            );
+        }
 
         log::info!("Node Address:     0x{}", hex::encode(address));
         log::info!(
@@ -62,12 +117,21 @@ impl DriaComputeNodeConfig {
             hex::encode(&secret_key.serialize()[0..1]),
             ".".repeat(64)
         );
+        if let Some(size) = max_batch_size {
+            log::info!("Max Batch Size:   {}", size);
+        }
+        log::info!(
+            "Heartbeat Interval: {:?}",
+            Duration::from_millis(heartbeat_interval)
+        );
 
         Self {
-            DKN_ADMIN_PUBLIC_KEY: admin_public_key,
+            DKN_ADMIN_PUBLIC_KEYS: admin_public_keys,
             DKN_WALLET_SECRET_KEY: secret_key,
             DKN_WALLET_PUBLIC_KEY: public_key,
             DKN_WALLET_ADDRESS: address,
+            DKN_MAX_BATCH_SIZE: max_batch_size,
+            DKN_HEARTBEAT_INTERVAL: Duration::from_millis(heartbeat_interval),
         }
     }
 }
@@ -78,6 +142,40 @@ impl Default for DriaComputeNodeConfig {
     }
 }
 
+/// Returns the selected Admin environment in upper snake case, e.g. `STAGING`, if any.
+fn admin_env() -> Option<String> {
+    env::var(DKN_ADMIN_ENV)
+        .ok()
+        .map(|admin_env| admin_env.trim().to_uppercase().replace('-', "_"))
+        .filter(|admin_env| !admin_env.is_empty())
+}
+
+/// Returns the env-var name of the Admin public keys, e.g. `DKN_ADMIN_PUBLIC_KEY_STAGING` for `STAGING`.
+fn admin_public_key_var(admin_env: Option<&str>) -> String {
+    match admin_env {
+        Some(admin_env) => format!("{}_{}", DKN_ADMIN_PUBLIC_KEY, admin_env),
+        None => DKN_ADMIN_PUBLIC_KEY.to_string(),
+    }
+}
+
+/// Parses the comma-separated Admin public keys of the given environment, invalid ones are skipped.
+fn parse_admin_public_keys(admin_env: Option<&str>) -> Vec<PublicKey> {
+    env::var(admin_public_key_var(admin_env))
+        .unwrap_or_default()
+        .split(',')
+        .map(|key_str| key_str.trim())
+        .filter(|key_str| !key_str.is_empty())
+        .filter_map(|key_str| {
+            PublicKey::parse_slice(
+                hex::decode(key_str).unwrap_or_default().as_slice(),
+                Some(PublicKeyFormat::Compressed),
+            )
+            .map_err(|_| log::warn!("Invalid Admin public key: {}, ignoring.", key_str))
+            .ok()
+        })
+        .collect()
+}
+
 #[cfg(test)]
 mod tests {
     use super::*;
@@ -94,4 +192,77 @@ mod tests {
             "1f56f6131705fbf19371122c80d7a2d40fcf9a68"
         );
     }
+
+    #[test]
+    fn test_admin_public_keys() {
+        let default_key = hex::encode(DEFAULT_DKN_ADMIN_PUBLIC_KEY);
+
+        env::set_var(
+            DKN_ADMIN_PUBLIC_KEY,
+            format!("{}, not-a-key,{}", default_key, default_key),
+        );
+        assert_eq!(DriaComputeNodeConfig::new().DKN_ADMIN_PUBLIC_KEYS.len(), 2);
+
+        // falls back to the default key
+        env::set_var(DKN_ADMIN_PUBLIC_KEY, "not-a-key");
+        let cfg = DriaComputeNodeConfig::new();
+        assert_eq!(cfg.DKN_ADMIN_PUBLIC_KEYS.len(), 1);
+        assert_eq!(
+            hex::encode(cfg.DKN_ADMIN_PUBLIC_KEYS[0].serialize_compressed()),
+            default_key
+        );
+
+        env::remove_var(DKN_ADMIN_PUBLIC_KEY);
+    }
+
+    #[test]
+    fn test_admin_env_public_keys() {
+        let default_key = hex::encode(DEFAULT_DKN_ADMIN_PUBLIC_KEY);
+        let staging_var = admin_public_key_var(Some("TEST_STAGING"));
+        assert_eq!(staging_var, "DKN_ADMIN_PUBLIC_KEY_TEST_STAGING");
+
+        // only the keys of the selected environment are read
+        env::set_var(&staging_var, &default_key);
+        let admin_public_keys = parse_admin_public_keys(Some("TEST_STAGING"));
+        assert_eq!(admin_public_keys.len(), 1);
+        assert_eq!(
+            hex::encode(admin_public_keys[0].serialize_compressed()),
+            default_key
+        );
+        assert!(parse_admin_public_keys(Some("TEST_PRODUCTION")).is_empty());
+
+        env::remove_var(&staging_var);
+    }
+
+    #[test]
+    fn test_heartbeat_interval() {
+        env::set_var(DKN_HEARTBEAT_INTERVAL, "500");
+        assert_eq!(
+            DriaComputeNodeConfig::new().DKN_HEARTBEAT_INTERVAL,
+            Duration::from_millis(500)
+        );
+
+        // out of range
+        env::set_var(DKN_HEARTBEAT_INTERVAL, "60000");
+        assert_eq!(
+            DriaComputeNodeConfig::new().DKN_HEARTBEAT_INTERVAL,
+            Duration::from_millis(DEFAULT_DKN_HEARTBEAT_INTERVAL)
+        );
+
+        env::remove_var(DKN_HEARTBEAT_INTERVAL);
+    }
+
+    #[test]
+    fn test_max_batch_size() {
+        env::set_var(DKN_MAX_BATCH_SIZE, "4");
+        assert_eq!(DriaComputeNodeConfig::new().DKN_MAX_BATCH_SIZE, Some(4));
+
+        env::set_var(DKN_MAX_BATCH_SIZE, "0");
+        assert_eq!(DriaComputeNodeConfig::new().DKN_MAX_BATCH_SIZE, None);
+
+        env::set_var(DKN_MAX_BATCH_SIZE, "many");
+        assert_eq!(DriaComputeNodeConfig::new().DKN_MAX_BATCH_SIZE, None);
+
+        env::remove_var(DKN_MAX_BATCH_SIZE);
+    }
 }
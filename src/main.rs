@@ -1,5 +1,6 @@
 use std::env;
 use std::sync::Arc;
+use std::time::Duration;
 use tokio_util::{sync::CancellationToken, task::TaskTracker};
 
 use dkn_compute::{
@@ -13,6 +14,9 @@ use dkn_compute::workers::heartbeat::*;
 use dkn_compute::workers::search_python::*;
 use dkn_compute::workers::synthesis::*;
 
+/// Time to wait for the ongoing tasks when stopping, shorter than the `stop_grace_period` of the compute container.
+const SHUTDOWN_TIMEOUT: Duration = Duration::from_secs(90);
+
 #[tokio::main]
 async fn main() -> Result<(), Box<dyn std::error::Error>> {
     env_logger::builder()
@@ -25,6 +29,7 @@ async fn main() -> Result<(), Box<dyn std::error::Error>> {
     let tasks = DriaComputeNodeTasks::new();
     let config = DriaComputeNodeConfig::new();
     let cancellation = CancellationToken::new();
+    let heartbeat_interval = config.DKN_HEARTBEAT_INTERVAL;
     let node = Arc::new(DriaComputeNode::new(config, cancellation.clone()));
 
     log::info!("Starting workers...");
@@ -39,7 +44,7 @@ async fn main() -> Result<(), Box<dyn std::error::Error>> {
     tracker.spawn(heartbeat_worker(
         node.clone(),
         "heartbeat",
-        tokio::time::Duration::from_millis(1000),
+        heartbeat_interval,
     ));
 
     if tasks.synthesis {
@@ -68,7 +73,20 @@ async fn main() -> Result<(), Box<dyn std::error::Error>> {
     // wait for all workers
     wait_for_termination(cancellation).await?;
     log::info!("Stopping workers");
-    tracker.wait().await;
+
+    // workers finish the tasks at hand before stopping, but do not take new ones
+    if node.is_busy() {
+        log::info!("Waiting for ongoing tasks to finish...");
+    }
+    if tokio::time::timeout(SHUTDOWN_TIMEOUT, tracker.wait())
+        .await
+        .is_err()
+    {
+        log::warn!(
+            "Ongoing tasks did not finish within {} seconds, stopping anyway.",
+            SHUTDOWN_TIMEOUT.as_secs()
+        );
+    }
 
     Ok(())
 }
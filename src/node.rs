@@ -176,10 +176,23 @@ impl DriaComputeNode {
         Ok(())
     }
 
+    /// Returns the index of the Admin public key that signed the given message, if any.
+    pub fn admin_signer(&self, message: &WakuMessage) -> Option<usize> {
+        self.config
+            .DKN_ADMIN_PUBLIC_KEYS
+            .iter()
+            .position(|public_key| {
+                message.is_signed(public_key).unwrap_or_else(|e| {
+                    log::warn!("Could not verify message signature: {}", e);
+                    false
+                })
+            })
+    }
+
     /// Process messages on a certain topic.
     ///
     /// If `signed=true` the messages are expected to be authentic, i.e. they
-    /// must be signed by Dria's public key.
+    /// must be signed by one of Dria's public keys.
     pub async fn process_topic(&self, topic: &str, signed: bool) -> NodeResult<Vec<WakuMessage>> {
         let content_topic = WakuMessage::create_content_topic(topic);
         let mut messages: Vec<WakuMessage> = self.waku.relay.get_messages(&content_topic).await?;
@@ -196,14 +209,7 @@ impl DriaComputeNode {
 
         // if signed, only keep messages that are authentic to Dria
         if signed {
-            messages.retain(|message| {
-                message
-                    .is_signed(&self.config.DKN_ADMIN_PUBLIC_KEY)
-                    .unwrap_or_else(|e| {
-                        log::warn!("Could not verify message signature: {}", e);
-                        false
-                    })
-            });
+            messages.retain(|message| self.admin_signer(message).is_some());
         }
 
         // sort messages with respect to their timestamp
@@ -217,6 +223,8 @@ impl DriaComputeNode {
     /// - parses them into their respective payloads
     /// - filters out past-deadline & non-selected (with the Bloom Filter) tasks
     /// - sorts the tasks by their deadline
+    ///
+    /// Batching with respect to `DKN_MAX_BATCH_SIZE` is left to the workers, see `TaskQueue::next_batch`.
     pub fn parse_messages<T>(&self, messages: Vec<WakuMessage>, signed: bool) -> Vec<TaskRequest<T>>
     where
         T: for<'a> Deserialize<'a>,
@@ -272,6 +280,7 @@ impl DriaComputeNode {
 
                 Some(TaskRequest {
                     task_id: task.task_id,
+                    deadline: task.deadline,
                     input: task.input,
                     public_key: task_public_key,
                 })
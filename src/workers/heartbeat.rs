@@ -32,7 +32,8 @@ pub fn heartbeat_worker(
                     break;
                 }
                 _ = tokio::time::sleep(sleep_amount) => {
-                    let messages = match node.process_topic(topic, true).await {
+                    // signatures are verified below, only once for each message
+                    let messages = match node.process_topic(topic, false).await {
                         Ok(messages) => {
                             messages
                         },
@@ -42,14 +43,15 @@ pub fn heartbeat_worker(
                         }
                     };
 
-                    // we only care about the latest heartbeat
-                    if let Some(message) = messages.last() {
-                        if node.is_busy() {
-                            log::info!("Node is busy, skipping heartbeat.");
-                            continue;
-                        }
-
+                    if messages.is_empty() {
+                        continue;
+                    }
+                    if node.is_busy() {
+                        log::info!("Node is busy, skipping heartbeat.");
+                        continue;
+                    }
 
+                    for message in latest_admin_messages(&node, &messages) {
                         log::info!("Received heartbeat: {}", message);
 
                         let message = match message.parse_payload::<HeartbeatPayload>(true) {
@@ -68,17 +70,34 @@ pub fn heartbeat_worker(
                         if let Err(e) = node.send_message_once(message).await {
                             log::error!("Error sending message: {}", e);
                         }
-
                     }
-
-
-
                 }
             }
         }
     })
 }
 
+/// Returns the latest message of each Admin Node, ignoring the ones that are not signed by an Admin Node.
+///
+/// Messages are expected to be sorted by timestamp.
+fn latest_admin_messages<'a>(
+    node: &DriaComputeNode,
+    messages: &'a [WakuMessage],
+) -> Vec<&'a WakuMessage> {
+    let mut signers = Vec::new();
+    let mut latest_messages = Vec::new();
+    for message in messages.iter().rev() {
+        match node.admin_signer(message) {
+            Some(signer) if !signers.contains(&signer) => {
+                signers.push(signer);
+                latest_messages.push(message);
+            }
+            _ => {}
+        }
+    }
+    latest_messages
+}
+
 #[cfg(test)]
 mod tests {
     use crate::{
@@ -1,12 +1,18 @@
+use futures::future::join_all;
 use std::sync::Arc;
 use std::time::Duration;
 
-use crate::{compute::search_python::SearchPythonClient, node::DriaComputeNode};
+use crate::{
+    compute::{payload::TaskRequest, queue::TaskQueue, search_python::SearchPythonClient},
+    node::DriaComputeNode,
+};
 
 /// # Search
 ///
 /// A search task tells the agent to search an information on the Web with a set of tools provided, such
 /// as web scrapers and search engine APIs.
+///
+/// Tasks of a batch, up to `DKN_MAX_BATCH_SIZE`, are processed concurrently.
 pub fn search_worker(
     node: Arc<DriaComputeNode>,
     topic: &'static str,
@@ -16,6 +22,7 @@ pub fn search_worker(
 
     tokio::spawn(async move {
         node.subscribe_topic(topic).await;
+        let mut queue = TaskQueue::new();
 
         loop {
             tokio::select! {
@@ -26,42 +33,70 @@ pub fn search_worker(
                     break;
                 }
                 _ = tokio::time::sleep(sleep_amount) => {
-                    let tasks = match node.process_topic(topic, true).await {
+                    // no new tasks are taken once the node is stopping
+                    if node.cancellation.is_cancelled() {
+                        continue;
+                    }
+
+                    match node.process_topic(topic, true).await {
                         Ok(messages) => {
-                            if messages.is_empty() {
-                                continue;
+                            if !messages.is_empty() {
+                                queue.push(node.parse_messages::<String>(messages, true));
                             }
-                            node.parse_messages::<String>(messages, true)
-                        }
-                        Err(e) => {
-                            log::error!("Error processing topic {}: {}", topic, e);
-                            continue;
                         }
+                        Err(e) => log::error!("Error processing topic {}: {}", topic, e),
                     };
 
-                    node.set_busy(true);
-                    log::info!("Received {} {} tasks.",  tasks.len(), topic);
-                    for task in &tasks {
-                        log::debug!("Task ID: {}", task.task_id);
+                    // tasks that do not fit in this batch stay in the queue for the next one
+                    let tasks = queue.next_batch(node.config.DKN_MAX_BATCH_SIZE);
+                    if tasks.is_empty() {
+                        continue;
                     }
-
-                    for task in tasks {
-                        let result = match search_client.search(task.input).await {
-                            Ok(result) => result,
-                            Err(e) => {
-                                log::error!("Error searching: {}", e);
-                                continue;
-                            }
-                        };
-
-                        if let Err(e) = node.send_task_result(&task.task_id, &task.public_key, result).await {
-                            log::error!("Error sending task result: {}", e);
-                        };
+                    // tasks read while the node was stopping are not started
+                    if node.cancellation.is_cancelled() {
+                        log::warn!("Node is stopping, dropping {} {} tasks.", tasks.len() + queue.len(), topic);
+                        continue;
+                    }
+                    if !queue.is_empty() {
+                        log::info!("{} {} tasks are queued for the next batch.", queue.len(), topic);
                     }
 
-                    node.set_busy(false);
+                    node.set_busy(true);
+                    log::info!("Received {} {} tasks.",  tasks.len(), topic);
+                    join_all(tasks.into_iter().map(|task| process_search_task(&node, &search_client, task))).await;
+
+                    // the node stays busy while there are queued tasks
+                    node.set_busy(!queue.is_empty());
                 }
             }
         }
     })
 }
+
+/// Searches for the query of a search task, and sends the result.
+async fn process_search_task(
+    node: &DriaComputeNode,
+    search_client: &SearchPythonClient,
+    task: TaskRequest<String>,
+) {
+    log::debug!("Task ID: {}", task.task_id);
+    if node.cancellation.is_cancelled() {
+        log::warn!("Node is stopping, dropping task {}.", task.task_id);
+        return;
+    }
+
+    let result = match search_client.search(task.input).await {
+        Ok(result) => result,
+        Err(e) => {
+            log::error!("Error searching: {}", e);
+            return;
+        }
+    };
+
+    if let Err(e) = node
+        .send_task_result(&task.task_id, &task.public_key, result)
+        .await
+    {
+        log::error!("Error sending task result: {}", e);
+    };
+}
@@ -1,16 +1,54 @@
-use std::sync::Arc;
-use std::time::Duration;
+use futures::future::join_all;
+use langchain_rust::language_models::llm::LLM;
+use std::sync::{Arc, Mutex};
+use std::time::{Duration, Instant};
 
 use crate::{
-    compute::llm::common::{create_llm, ModelProvider},
+    compute::{
+        llm::common::{create_llms, invoke_llm, is_api_key_error, ModelProvider},
+        payload::TaskRequest,
+        queue::TaskQueue,
+    },
     config::constants::*,
     node::DriaComputeNode,
 };
 
+/// Duration for which an API key is skipped after an authentication, rate-limit or quota error.
+const API_KEY_COOLDOWN: Duration = Duration::from_secs(60);
+
+/// An LLM client with the index of its provider, its name for the logs, and its timeout.
+struct NamedLLM {
+    provider_index: usize,
+    name: String,
+    llm: Box<dyn LLM>,
+    timeout: Option<Duration>,
+    /// Until when the client is skipped, after its API key has failed.
+    cooldown_until: Mutex<Option<Instant>>,
+}
+
+impl NamedLLM {
+    fn is_cooling_down(&self) -> bool {
+        let cooldown_until = self.cooldown_until.lock().unwrap();
+        cooldown_until.is_some_and(|until| Instant::now() < until)
+    }
+
+    fn cool_down(&self) {
+        *self.cooldown_until.lock().unwrap() = Some(Instant::now() + API_KEY_COOLDOWN);
+    }
+}
+
 /// # Synthesis
 ///
 /// A synthesis task is the task of putting a prompt to an LLM and obtaining many results, essentially growing the number of data points in a dataset,
 /// hence creating synthetic data.
+///
+/// Multiple providers can be given in order of preference, in which case a task that fails
+/// (or times out) with one provider is retried with the next one. Likewise, OpenAI tries each of
+/// its API keys in order, but only on authentication and rate-limit errors, as other errors such
+/// as timeouts would most likely repeat with the other keys. A key that failed this way is skipped
+/// for `API_KEY_COOLDOWN`.
+///
+/// Tasks of a batch, up to `DKN_MAX_BATCH_SIZE`, are processed concurrently.
 pub fn synthesis_worker(
     node: Arc<DriaComputeNode>,
     topic: &'static str,
@@ -19,18 +57,42 @@ pub fn synthesis_worker(
     model_name: Option<String>,
 ) -> tokio::task::JoinHandle<()> {
     tokio::spawn(async move {
-        let (model_provider, model_name) = parse_model_info(model_provider, model_name);
-        log::info!("Using {} with {}", model_provider, model_name);
+        let mut llms: Vec<NamedLLM> = Vec::new();
+        let model_infos = parse_model_infos(model_provider, model_name);
+        for (provider_index, (model_provider, model_name)) in model_infos.into_iter().enumerate() {
+            log::info!("Using {} with {}", model_provider, model_name);
+            let name = format!("{} ({})", model_provider, model_name);
+            let timeout = model_provider.timeout();
 
-        let llm = match create_llm(model_provider, model_name, node.cancellation.clone()).await {
-            Ok(llm) => llm,
-            Err(e) => {
-                log::error!("Could not create LLM: {}, exiting worker.", e);
-                return;
-            }
-        };
+            match create_llms(model_provider, model_name, node.cancellation.clone()).await {
+                Ok(clients) => {
+                    // number the clients if there are many, e.g. one for each OpenAI API key
+                    let numbered = clients.len() > 1;
+                    for (i, llm) in clients.into_iter().enumerate() {
+                        let name = if numbered {
+                            format!("{} #{}", name, i + 1)
+                        } else {
+                            name.clone()
+                        };
+                        llms.push(NamedLLM {
+                            provider_index,
+                            name,
+                            llm,
+                            timeout,
+                            cooldown_until: Mutex::new(None),
+                        });
+                    }
+                }
+                Err(e) => log::error!("Could not create LLM {}: {}", name, e),
+            };
+        }
+        if llms.is_empty() {
+            log::error!("Could not create any LLM, exiting worker.");
+            return;
+        }
 
         node.subscribe_topic(topic).await;
+        let mut queue = TaskQueue::new();
 
         loop {
             tokio::select! {
@@ -41,44 +103,96 @@ pub fn synthesis_worker(
                     break;
                 }
                 _ = tokio::time::sleep(sleep_amount) => {
-                    let tasks = match node.process_topic(topic, true).await {
+                    // no new tasks are taken once the node is stopping
+                    if node.cancellation.is_cancelled() {
+                        continue;
+                    }
+
+                    match node.process_topic(topic, true).await {
                         Ok(messages) => {
-                            if messages.is_empty() {
-                                continue;
+                            if !messages.is_empty() {
+                                queue.push(node.parse_messages::<String>(messages, true));
                             }
-                            node.parse_messages::<String>(messages, true)
-                        }
-                        Err(e) => {
-                            log::error!("Error processing topic {}: {}", topic, e);
-                            continue;
                         }
+                        Err(e) => log::error!("Error processing topic {}: {}", topic, e),
                     };
 
+                    // tasks that do not fit in this batch stay in the queue for the next one
+                    let tasks = queue.next_batch(node.config.DKN_MAX_BATCH_SIZE);
+                    if tasks.is_empty() {
+                        continue;
+                    }
+                    // tasks read while the node was stopping are not started
+                    if node.cancellation.is_cancelled() {
+                        log::warn!("Node is stopping, dropping {} {} tasks.", tasks.len() + queue.len(), topic);
+                        continue;
+                    }
+                    if !queue.is_empty() {
+                        log::info!("{} {} tasks are queued for the next batch.", queue.len(), topic);
+                    }
+
                     node.set_busy(true);
                     log::info!("Processing {} {} tasks.", tasks.len(), topic);
-                    for task in &tasks {
-                        log::debug!("Task ID: {}", task.task_id);
-                    }
+                    join_all(tasks.into_iter().map(|task| process_synthesis_task(&node, &llms, task))).await;
 
-                    for task in tasks {
-                        let llm_result = match llm.invoke(&task.input).await {
-                            Ok(result) => result,
-                            Err(e) => {
-                                log::error!("Error generating prompt result: {}", e);
-                                continue;
-                            }
-                        };
+                    // the node stays busy while there are queued tasks
+                    node.set_busy(!queue.is_empty());
+                }
+            }
+        }
+    })
+}
 
-                        if let Err(e) = node.send_task_result(&task.task_id, &task.public_key, llm_result).await {
-                            log::error!("Error sending task result: {}", e);
-                        };
-                    }
+/// Generates the result of a synthesis task with the first LLM that succeeds, and sends it.
+async fn process_synthesis_task(
+    node: &DriaComputeNode,
+    llms: &[NamedLLM],
+    task: TaskRequest<String>,
+) {
+    log::debug!("Task ID: {}", task.task_id);
 
-                    node.set_busy(false);
+    let mut llm_result = None;
+    let mut failed_provider = None;
+    for llm in llms {
+        // other API keys of a failed provider are skipped, unless the failure was due to the API key
+        if failed_provider == Some(llm.provider_index) {
+            continue;
+        }
+        // no LLM is tried once the node is stopping, including a fallback after a failure
+        if node.cancellation.is_cancelled() {
+            log::warn!("Node is stopping, dropping task {}.", task.task_id);
+            return;
+        }
+        if llm.is_cooling_down() {
+            log::debug!("Skipping {} as its API key has recently failed.", llm.name);
+            continue;
+        }
+
+        match invoke_llm(llm.llm.as_ref(), &task.input, llm.timeout).await {
+            Ok(result) => {
+                llm_result = Some(result);
+                break;
+            }
+            Err(e) => {
+                log::error!("Error generating prompt result with {}: {}", llm.name, e);
+                if is_api_key_error(&e) {
+                    llm.cool_down();
+                } else {
+                    failed_provider = Some(llm.provider_index);
                 }
             }
         }
-    })
+    }
+    let Some(llm_result) = llm_result else {
+        return;
+    };
+
+    if let Err(e) = node
+        .send_task_result(&task.task_id, &task.public_key, llm_result)
+        .await
+    {
+        log::error!("Error sending task result: {}", e);
+    };
 }
 
 /// Given a model provier option, and a model name option, return the model provider and model name.
@@ -103,3 +217,66 @@ pub fn parse_model_info(
 
     (model_provider, model_name)
 }
+
+/// Given comma-separated model providers and model names, returns the model provider and model name pairs
+/// in the given order.
+///
+/// Model names are matched to the model providers by their position, and a missing or empty model name
+/// defaults with respect to its model provider. If no model provider is given, it will default.
+pub fn parse_model_infos(
+    model_providers: Option<String>,
+    model_names: Option<String>,
+) -> Vec<(ModelProvider, String)> {
+    let model_providers = model_providers.unwrap_or_default();
+    let model_names = model_names.unwrap_or_default();
+    let mut model_names = model_names.split(',').map(|name| name.trim().to_string());
+
+    let providers: Vec<&str> = model_providers
+        .split(',')
+        .map(|provider| provider.trim())
+        .filter(|provider| !provider.is_empty())
+        .collect();
+    if providers.is_empty() {
+        let model_name = model_names.next().filter(|name| !name.is_empty());
+        return vec![parse_model_info(None, model_name)];
+    }
+
+    providers
+        .into_iter()
+        .map(|provider| {
+            let model_name = model_names.next().filter(|name| !name.is_empty());
+            parse_model_info(Some(provider.to_string()), model_name)
+        })
+        .collect()
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_parse_model_infos() {
+        let infos = parse_model_infos(
+            Some("ollama, openai".to_string()),
+            Some("llama3,gpt-4o".to_string()),
+        );
+        assert_eq!(infos.len(), 2);
+        assert!(matches!(infos[0].0, ModelProvider::Ollama));
+        assert_eq!(infos[0].1, "llama3");
+        assert!(matches!(infos[1].0, ModelProvider::OpenAI));
+        assert_eq!(infos[1].1, "gpt-4o");
+
+        // missing model names default
+        let infos = parse_model_infos(
+            Some("openai,ollama".to_string()),
+            Some("gpt-4o".to_string()),
+        );
+        assert_eq!(infos[1].1, DEFAULT_DKN_SYNTHESIS_MODEL_NAME_OLLAMA);
+
+        // missing model provider defaults
+        let infos = parse_model_infos(None, None);
+        assert_eq!(infos.len(), 1);
+        assert!(matches!(infos[0].0, ModelProvider::Ollama));
+        assert_eq!(infos[0].1, DEFAULT_DKN_SYNTHESIS_MODEL_NAME_OLLAMA);
+    }
+}
@@ -5,12 +5,21 @@ docs() {
         start.sh starts the compute node with given environment and parameters using docker-compose.
         Loads the .env file as base environment and creates a .env.compose file for final environment to run with docker-compose.
         Required environment variables in .env file; ETH_CLIENT_ADDRESS, ETH_TESTNET_KEY, RLN_RELAY_CRED_PASSWORD
+        Containers are started under the docker-compose project DKN_COMPOSE_PROJECT_NAME (default: dkn-compute-node).
+
+        Hook scripts can be given with DKN_HOOK_PRE_START, DKN_HOOK_POST_START and DKN_HOOK_ON_FAILURE env-vars, these are run
+        before docker-compose up, after docker-compose up, and when the start fails for any reason respectively. A failing pre-start hook aborts the start.
+        DKN_HOOK_PRE_UPDATE is run before a running node is recreated with a changed configuration, and aborts the update if it fails.
+        DKN_HOOK_ON_CRASH is run when the node exits with an error in --from-source mode, containers are restarted by Docker instead.
+        Hooks are run as executables, with DKN_HOOK_EVENT, DKN_HOOK_EXIT_CODE, DKN_COMPOSE_PROJECT_NAME and COMPOSE_PROFILES env-vars.
+        Each event is also posted as JSON to DKN_HOOK_WEBHOOK, if given.
         
         Description of command-line arguments:
             --synthesis: Runs the node for the synthesis tasks. Can be set as DKN_TASKS="synthesis" env-var (default: false, required for search tasks)
             --search: Runs the node for the search tasks. Can be set as DKN_TASKS="search" env-var (default: false, required for synthesis tasks)
 
             --synthesis-model-provider=<arg>: Indicates the model provider for synthesis tasks, ollama or openai. Can be set as DKN_SYNTHESIS_MODEL_PROVIDER env-var (required on synthesis tasks)
+                A comma-separated list such as ollama,openai sets the fallback order, with the models given in the same order in --synthesis-model.
             --search-model-provider=<arg>: Indicates the model provider for search tasks, ollama or openai. Can be set as AGENT_MODEL_PROVIDER env-var (required on search tasks)
 
             --synthesis-model: Indicates the model for synthesis tasks, model needs to be compatible with the given provider. Can be set as DKN_SYNTHESIS_MODEL_NAME env-var (required on synthesis tasks) 
@@ -18,7 +27,35 @@ docs() {
 
             --local-ollama=<true/false>: Indicates the local Ollama environment is being used (default: true)
 
-            --dev: Sets the logging level to debug (default: info)
+            --privacy: Keeps optional API keys (OpenAI, Serper, Browserless, Anthropic) for this session only. They are not written to .env.compose,
+                and the ones in env files on disk are ignored. Give them in the environment or with --env-file=-, otherwise the OpenAI API key
+                is asked for on every start (default: false)
+
+            --admin-env=<name>: Trusts only the Admin Node of the given environment, whose public keys are read from DKN_ADMIN_PUBLIC_KEY_<NAME>,
+                e.g. --admin-env=staging reads DKN_ADMIN_PUBLIC_KEY_STAGING. Can be set as DKN_ADMIN_ENV env-var (default: uses DKN_ADMIN_PUBLIC_KEY)
+
+            --preset=<eco/balanced/max-performance>: Applies a launch preset, variables set in env files take precedence over the preset.
+                eco: unloads the model right after use, processes one task per batch while the rest wait in the queue, checks heartbeats every 2 seconds,
+                    and uses phi3 for Ollama synthesis (OLLAMA_KEEP_ALIVE=0, DKN_MAX_BATCH_SIZE=1, DKN_HEARTBEAT_INTERVAL=2000, DKN_SYNTHESIS_MODEL_NAME=phi3)
+                balanced: keeps the model loaded for 5 minutes after use, derives the batch size from your hardware, checks heartbeats every second,
+                    and uses phi3 for Ollama synthesis (OLLAMA_KEEP_ALIVE=5m, DKN_MAX_BATCH_SIZE=auto, DKN_HEARTBEAT_INTERVAL=1000, DKN_SYNTHESIS_MODEL_NAME=phi3)
+                max-performance: keeps the model loaded at all times, processes all received tasks at once, checks heartbeats every half second,
+                    and uses llama3 for Ollama synthesis (OLLAMA_KEEP_ALIVE=-1, DKN_MAX_BATCH_SIZE=0, DKN_HEARTBEAT_INTERVAL=500, DKN_SYNTHESIS_MODEL_NAME=llama3)
+
+            --env-file=<path>: Loads environment variables from the given file instead of .env, can be given multiple times.
+                Files are loaded in the given order, so a variable in a later file overrides the same variable in an earlier one.
+                Command-line arguments override the variables from all env files. Use --env-file=- to read from stdin.
+            --export-config[=<env/json>]: Prints the resolved configuration without secrets, i.e. env files, preset and command-line arguments combined,
+                and exits without starting the node. The env format (default) can be read back with --env-file=-.
+
+            --from-source[=<path>]: Runs the compute node from source with cargo instead of the compute container, other services still run with docker-compose.
+                The source is built at the given path, e.g. --from-source=~/dev/dkn-compute-node (default: current directory).
+                The node is restarted whenever it is rebuilt with cargo build --release, e.g. from another terminal.
+                Runs in foreground mode, requires Rust to be installed (default: false)
+
+            --dev, --verbose: Shows debug messages of this script, and sets the logging level of the node to debug (default: info)
+            -q, --quiet: Only shows warnings and errors, both of this script and the node (default: info)
+                Output is colored on terminals, set NO_COLOR to disable it.
             -b, --background: Enables background mode for running the node (default: FOREGROUND)
             -h, --help: Displays this help message
 
@@ -30,16 +67,147 @@ docs() {
     exit 0
 }
 
-echo "************ DKN - Compute Node ************"
+# launcher output levels, --quiet only shows warnings and errors, --verbose also shows debug messages
+LOG_LEVEL_ERROR=0
+LOG_LEVEL_WARN=1
+LOG_LEVEL_INFO=2
+LOG_LEVEL_DEBUG=3
+LAUNCHER_LOG_LEVEL=$LOG_LEVEL_INFO
+
+# prints a timestamped message at the given level, colored if the output is a terminal and NO_COLOR is not set
+# the first argument is the message, the rest are printed as-is on the following lines
+print_log() {
+    local level="$1" label="$2" color="$3"
+    shift 3
+    if [ "$level" -gt "$LAUNCHER_LOG_LEVEL" ]; then
+        return
+    fi
+
+    local prefix="$(date '+%Y-%m-%d %H:%M:%S') ${label}"
+    if [ -t 2 ] && [ -z "$NO_COLOR" ]; then
+        prefix="\033[${color}m${prefix}\033[0m"
+    fi
+    printf "%b %s\n" "$prefix" "$1" >&2
+    shift
+    for line in "$@"; do
+        printf "%s\n" "$line" >&2
+    done
+}
+log_error() { print_log $LOG_LEVEL_ERROR "ERROR" "31" "$@"; }
+log_warn() { print_log $LOG_LEVEL_WARN "WARN " "33" "$@"; }
+log_info() { print_log $LOG_LEVEL_INFO "INFO " "32" "$@"; }
+log_debug() { print_log $LOG_LEVEL_DEBUG "DEBUG" "36" "$@"; }
 
-# if .env exists, load it first
-ENV_FILE=".env"
+# collect env files given with --env-file and the output level, these are handled before other arguments
+ENV_FILES=()
+for arg in "$@"; do
+    case $arg in
+        --env-file=*) ENV_FILES+=("${arg#*=}") ;;
+        --dev|--verbose) LAUNCHER_LOG_LEVEL=$LOG_LEVEL_DEBUG ;;
+        -q|--quiet) LAUNCHER_LOG_LEVEL=$LOG_LEVEL_WARN ;;
+    esac
+done
+
+log_info "************ DKN - Compute Node ************"
+
+# optional API keys given in the environment of this script or from stdin are kept apart as SESSION_<name>,
+# so that --privacy can ignore the ones read from env files on disk
+OPTIONAL_KEY_VARS=("OPENAI_API_KEY" "OPENAI_FALLBACK_API_KEYS" "SERPER_API_KEY" "BROWSERLESS_TOKEN" "ANTHROPIC_API_KEY")
+# if the content of an env file read from stdin is given, only the keys set by it are kept
+keep_session_keys() {
+    for var in "${OPTIONAL_KEY_VARS[@]}"; do
+        local value="${!var}"
+        if [ -n "$1" ]; then
+            value=$(unset "$var"; eval "$1" > /dev/null; printf '%s' "${!var}")
+        fi
+        if [ -n "$value" ]; then
+            printf -v "SESSION_$var" '%s' "$value"
+        fi
+    done
+}
+keep_session_keys
+
+# load env files in the given order, so that later files override the earlier ones
+# if no env file is given, load .env if it exists
 ENV_COMPOSE_FILE=".env.compose"
-if [ -f "$ENV_FILE" ]; then
-  set -o allexport
-  source "$ENV_FILE"
-  set +o allexport
+if [ ${#ENV_FILES[@]} -eq 0 ]; then
+    if [ -f ".env" ]; then
+        ENV_FILES+=(".env")
+    fi
 fi
+for env_file in "${ENV_FILES[@]}"; do
+    # "-" reads the environment from stdin, e.g. when piped from a secret manager
+    if [ "$env_file" == "-" ]; then
+        stdin_env="$(cat)"
+        set -o allexport
+        eval "$stdin_env"
+        set +o allexport
+        keep_session_keys "$stdin_env"
+        continue
+    fi
+
+    if [ ! -f "$env_file" ]; then
+        log_error "Env file not found: $env_file"
+        exit 1
+    fi
+    set -o allexport
+    source "$env_file"
+    set +o allexport
+done
+
+# docker-compose project name, set explicitly so that other projects are not affected
+DKN_COMPOSE_PROJECT_NAME="${DKN_COMPOSE_PROJECT_NAME:-dkn-compute-node}"
+DKN_COMPOSE_LABEL="xyz.firstbatch.dkn=compute-node"
+
+# runs the hook script at the given env-var if it is set, returns the exit code of the script
+# the script is run as an executable, so its shebang is respected
+run_hook() {
+    local hook_var="$1"
+    local hook="${!hook_var}"
+    notify_webhook "$2" "${3:-0}"
+    if [ -z "$hook" ]; then
+        return 0
+    fi
+    if [[ "$hook" != */* ]]; then
+        hook="./$hook"
+    fi
+
+    log_info "Running ${hook_var}: ${hook}"
+    DKN_HOOK_EVENT="$2" \
+    DKN_HOOK_EXIT_CODE="${3:-0}" \
+    DKN_COMPOSE_PROJECT_NAME="$DKN_COMPOSE_PROJECT_NAME" \
+    COMPOSE_PROFILES="$HOOK_COMPOSE_PROFILES" \
+        "$hook"
+}
+
+# posts the given event and exit code to DKN_HOOK_WEBHOOK as JSON, a failing webhook only logs a warning
+notify_webhook() {
+    if [ -z "$DKN_HOOK_WEBHOOK" ]; then
+        return 0
+    fi
+
+    local payload="{\"event\":\"$1\",\"exit_code\":$2,\"project\":\"${DKN_COMPOSE_PROJECT_NAME}\",\"profiles\":\"${HOOK_COMPOSE_PROFILES}\"}"
+    log_debug "Posting $1 event to webhook"
+    curl -fsS -m 10 -X POST -H "Content-Type: application/json" -d "$payload" "$DKN_HOOK_WEBHOOK" > /dev/null ||
+        log_warn "Could not post $1 event to webhook"
+}
+
+# run the on-failure hook whenever the script exits with an error, e.g. on a missing env-var or a docker-compose failure
+on_exit() {
+    local exit_code=$?
+    if [ $exit_code -ne 0 ]; then
+        run_hook "DKN_HOOK_ON_FAILURE" "on-failure" "$exit_code"
+    fi
+}
+trap on_exit EXIT
+
+# checks whether the given service of this compose project has a running container
+is_service_running() {
+    [ -n "$(docker ps -q \
+        --filter "label=com.docker.compose.project=${DKN_COMPOSE_PROJECT_NAME}" \
+        --filter "label=com.docker.compose.service=$1" \
+        --filter "label=${DKN_COMPOSE_LABEL}")" ]
+}
 
 # flag vars
 COMPUTE_SEARCH=false
@@ -48,6 +216,11 @@ START_MODE="FOREGROUND"
 LOCAL_OLLAMA=true
 LOGS="info"
 EXTERNAL_WAKU=false
+FROM_SOURCE=false
+SOURCE_PATH="."
+PRESET=""
+PRIVACY_MODE=false
+EXPORT_FORMAT=""
 
 # script internal
 COMPOSE_PROFILES=()
@@ -86,36 +259,221 @@ while [[ "$#" -gt 0 ]]; do
             LOCAL_OLLAMA="$(echo "${1#*=}" | tr '[:upper:]' '[:lower:]')"
         ;;
 
+        --privacy)
+            PRIVACY_MODE=true
+        ;;
+
+        --admin-env=*)
+            DKN_ADMIN_ENV="${1#*=}"
+        ;;
+        --preset=*)
+            PRESET="$(echo "${1#*=}" | tr '[:upper:]' '[:lower:]')"
+        ;;
+
+        --env-file=*)
+            # already loaded above
+        ;;
+        --export-config)
+            EXPORT_FORMAT="env"
+        ;;
+        --export-config=*)
+            EXPORT_FORMAT="$(echo "${1#*=}" | tr '[:upper:]' '[:lower:]')"
+        ;;
+
         --waku-ext)
             EXTERNAL_WAKU=true
         ;;
 
-        --dev)
+        --from-source)
+            FROM_SOURCE=true
+        ;;
+        --from-source=*)
+            FROM_SOURCE=true
+            SOURCE_PATH="${1#*=}"
+            SOURCE_PATH="${SOURCE_PATH/#\~/$HOME}"
+        ;;
+
+        --dev|--verbose)
             DKN_LOG_LEVEL="none,dkn_compute=debug"
         ;;
+        -q|--quiet)
+            DKN_LOG_LEVEL="warn"
+        ;;
         -b|--background) START_MODE="BACKGROUND" ;;
         -h|--help) docs ;;
-        *) echo "ERROR: Unknown parameter passed: $1"; exit 1 ;;
+        *) log_error "Unknown parameter passed: $1"; exit 1 ;;
     esac
     shift
 done
 
+# the source path must be a checkout of the compute node
+if [ "$FROM_SOURCE" == true ] && [ ! -f "${SOURCE_PATH}/Cargo.toml" ]; then
+    log_error "No Cargo.toml found at ${SOURCE_PATH}, --from-source expects a checkout of the compute node."
+    exit 1
+fi
+
+# finds the docker compose command, the compose plugin is preferred over the standalone docker-compose
+DOCKER_COMPOSE=""
+find_docker_compose() {
+    if docker compose version &> /dev/null; then
+        DOCKER_COMPOSE="docker compose"
+    elif command -v docker-compose &> /dev/null; then
+        DOCKER_COMPOSE="docker-compose"
+    fi
+}
+
+# installs Docker Engine with the compose plugin using the official convenience script, asks for confirmation first
+install_docker_linux() {
+    if ! command -v curl &> /dev/null; then
+        log_error "curl is required to install Docker Engine."
+        return 1
+    fi
+
+    local answer=""
+    read -r -p "Do you want to install Docker Engine with https://get.docker.com now? [y/N] " answer < /dev/tty
+    if [[ ! "$answer" =~ ^[Yy]$ ]]; then
+        return 1
+    fi
+
+    # the script asks for sudo by itself when it is not run as root
+    curl -fsSL https://get.docker.com | sh || return 1
+    if command -v systemctl &> /dev/null; then
+        sudo systemctl enable --now docker
+    fi
+
+    if ! docker info &> /dev/null; then
+        log_error "Docker Engine is installed, but your user can not access it yet." \
+            "Add your user to the docker group, then log in again and re-run this script:" \
+            "    sudo usermod -aG docker \$USER"
+        exit 1
+    fi
+}
+
+# docker and docker compose are required to run the node, guide the user to install them if they are missing
+check_docker() {
+    find_docker_compose
+    if command -v docker &> /dev/null && [ -n "$DOCKER_COMPOSE" ]; then
+        return
+    fi
+
+    log_error "Docker is not installed, or Docker Compose is missing."
+    case "$(uname -s)" in
+        Darwin)
+            log_error "Install Docker Desktop from https://docs.docker.com/desktop/install/mac-install/" \
+                "or with Homebrew: brew install --cask docker"
+        ;;
+        Linux)
+            if install_docker_linux; then
+                find_docker_compose
+                if [ -n "$DOCKER_COMPOSE" ]; then
+                    log_info "Docker is installed, continuing setup."
+                    return
+                fi
+            fi
+            log_error "Install Docker Engine with the compose plugin using the convenience script:" \
+                "    curl -fsSL https://get.docker.com | sh" \
+                "or follow https://docs.docker.com/engine/install/"
+        ;;
+        *)
+            log_error "Install Docker Desktop from https://docs.docker.com/get-docker/"
+        ;;
+    esac
+    exit 1
+}
+check_docker
+
+# compose.yml uses optional service dependencies, which require Docker Compose v2.20 or newer
+check_compose_version() {
+    local version major minor
+    version=$($DOCKER_COMPOSE version --short 2> /dev/null | sed 's/^v//')
+    IFS=. read -r major minor _ <<< "$version"
+    if [[ "$major" =~ ^[0-9]+$ ]] && [[ "$minor" =~ ^[0-9]+$ ]]; then
+        if [ "$major" -gt 2 ] || { [ "$major" -eq 2 ] && [ "$minor" -ge 20 ]; }; then
+            return
+        fi
+    fi
+
+    log_error "Docker Compose v2.20 or newer is required, found ${version:-an unknown version} with ${DOCKER_COMPOSE}." \
+        "Update Docker Desktop, or install the compose plugin: https://docs.docker.com/compose/install/"
+    exit 1
+}
+check_compose_version
+
+# apply launch preset, if any, only to the variables that are not already set by env files
+handle_preset() {
+    local preset_model=""
+    case "$PRESET" in
+        "") return ;;
+        eco)
+            : "${OLLAMA_KEEP_ALIVE:=0}"
+            : "${DKN_MAX_BATCH_SIZE:=1}"
+            : "${DKN_HEARTBEAT_INTERVAL:=2000}"
+            preset_model="phi3"
+        ;;
+        balanced)
+            : "${OLLAMA_KEEP_ALIVE:=5m}"
+            : "${DKN_MAX_BATCH_SIZE:=auto}"
+            : "${DKN_HEARTBEAT_INTERVAL:=1000}"
+            preset_model="phi3"
+        ;;
+        max-performance)
+            : "${OLLAMA_KEEP_ALIVE:=-1}"
+            : "${DKN_MAX_BATCH_SIZE:=0}"
+            : "${DKN_HEARTBEAT_INTERVAL:=500}"
+            preset_model="llama3"
+        ;;
+        *) log_error "Unknown preset: $PRESET, expected one of eco, balanced, max-performance"; exit 1 ;;
+    esac
+
+    # the model is only chosen for Ollama synthesis, other providers have no local model to size
+    if [ -z "$DKN_SYNTHESIS_MODEL_NAME" ] && [ "$(echo "$DKN_SYNTHESIS_MODEL_PROVIDER" | tr -d '[:space:]' | tr '[:upper:]' '[:lower:]')" == "ollama" ]; then
+        DKN_SYNTHESIS_MODEL_NAME="$preset_model"
+    fi
+
+    # exported for docker-compose and ollama serve
+    export OLLAMA_KEEP_ALIVE
+}
+handle_preset
+
+# the admin environment selects the Admin Node public keys to trust, e.g. staging reads DKN_ADMIN_PUBLIC_KEY_STAGING
+ADMIN_PUBLIC_KEY_VAR="DKN_ADMIN_PUBLIC_KEY"
+if [ -n "$DKN_ADMIN_ENV" ]; then
+    ADMIN_PUBLIC_KEY_VAR="DKN_ADMIN_PUBLIC_KEY_$(echo "$DKN_ADMIN_ENV" | tr '[:lower:]-' '[:upper:]_' | tr -d '[:space:]')"
+    log_info "Using Admin environment $DKN_ADMIN_ENV with $ADMIN_PUBLIC_KEY_VAR"
+fi
+
 check_required_env_vars() {
     local required_vars=(
         "ETH_CLIENT_ADDRESS"
         "ETH_TESTNET_KEY"
         "RLN_RELAY_CRED_PASSWORD"
         "DKN_WALLET_SECRET_KEY"
-        "DKN_ADMIN_PUBLIC_KEY"
+        "$ADMIN_PUBLIC_KEY_VAR"
     )
     for var in "${required_vars[@]}"; 
     do
         if [ -z "${!var}" ]; 
         then
-            echo "ERROR: $var environment variable is not set."
+            log_error "$var environment variable is not set."
             exit 1
         fi
     done
+
+    # the node skips invalid Admin public keys, and refuses to start if none of the selected ones is valid
+    local valid_admin_keys=0
+    IFS=',' read -ra admin_keys <<< "${!ADMIN_PUBLIC_KEY_VAR}"
+    for admin_key in "${admin_keys[@]}"; do
+        admin_key="$(echo "$admin_key" | tr -d '[:space:]')"
+        if [[ "$admin_key" =~ ^0[23][0-9a-fA-F]{64}$ ]]; then
+            valid_admin_keys=$((valid_admin_keys + 1))
+        elif [ -n "$admin_key" ]; then
+            log_warn "Invalid Admin public key in $ADMIN_PUBLIC_KEY_VAR: $admin_key, it will be ignored."
+        fi
+    done
+    if [ $valid_admin_keys -eq 0 ]; then
+        log_error "$ADMIN_PUBLIC_KEY_VAR has no valid Admin public keys, expected 33-byte compressed public keys in hex."
+        exit 1
+    fi
 }
 check_required_env_vars
 
@@ -147,25 +505,30 @@ as_pairs() {
     echo "${pairs[@]}"
 }
 
-echo "Handling the environment..."
+log_info "Handling the environment..."
 
 # this function handles all compute related environment, compute_envs is a list of "name=value" env-var pairs
 compute_envs=()
 handle_compute_env() {
     compute_env_vars=(
         "DKN_WALLET_SECRET_KEY"
-        "DKN_ADMIN_PUBLIC_KEY"
+        "DKN_ADMIN_ENV"
+        "$ADMIN_PUBLIC_KEY_VAR"
         "DKN_TASKS"
         "DKN_SYNTHESIS_MODEL_PROVIDER"
         "DKN_SYNTHESIS_MODEL_NAME"
         "AGENT_MODEL_PROVIDER"
         "AGENT_MODEL_NAME"
-        "OPENAI_API_KEY"
-        "SERPER_API_KEY"
-        "BROWSERLESS_TOKEN"
-        "ANTHROPIC_API_KEY"
+        "OPENAI_TIMEOUT"
         "DKN_LOG_LEVEL"
+        "DKN_MAX_BATCH_SIZE"
+        "DKN_HEARTBEAT_INTERVAL"
     )
+
+    # optional API keys, in privacy mode these are passed to docker-compose via environment instead of .env.compose
+    if [ "$PRIVACY_MODE" == false ]; then
+        compute_env_vars+=("${OPTIONAL_KEY_VARS[@]}")
+    fi
     compute_envs=($(as_pairs "${compute_env_vars[@]}"))
 
     # handle DKN_TASKS
@@ -189,7 +552,7 @@ handle_compute_env() {
                 fi
             done
         else
-            echo "ERROR: No task type has given, --synthesis and/or --search flags are required"
+            log_error "No task type has given, --synthesis and/or --search flags are required"
             exit 1
         fi
     fi
@@ -197,7 +560,7 @@ handle_compute_env() {
     # check model providers, they are required
     if [ "$COMPUTE_SEARCH" = true ]; then
         if [ -z "$AGENT_MODEL_PROVIDER" ]; then
-            echo "ERROR: Search model provider is required on search tasks. Example usage; --search-model-provider=ollama"
+            log_error "Search model provider is required on search tasks. Example usage; --search-model-provider=ollama"
             exit 1
         fi
         # then all lowercase
@@ -206,7 +569,7 @@ handle_compute_env() {
     fi
     if [ "$COMPUTE_SYNTHESIS" = true ]; then
         if [ -z "$DKN_SYNTHESIS_MODEL_PROVIDER" ]; then
-            echo "ERROR: Synthesis model provider is required on synthesis tasks. Example usage; --synthesis-model-provider=ollama"
+            log_error "Synthesis model provider is required on synthesis tasks. Example usage; --synthesis-model-provider=ollama"
             exit 1
         fi
         # then all lowercase
@@ -218,6 +581,90 @@ handle_compute_env() {
 }
 handle_compute_env
 
+# in privacy mode, optional API keys are only taken from the environment of this script or from stdin, and asked for if missing
+# they are passed to docker-compose through the environment, see compose.privacy.yml
+handle_privacy_keys() {
+    if [ "$PRIVACY_MODE" == false ]; then
+        return
+    fi
+
+    for var in "${OPTIONAL_KEY_VARS[@]}"; do
+        local session_var="SESSION_$var"
+        if [ -n "${!var}" ] && [ -z "${!session_var}" ]; then
+            log_warn "$var is read from an env file on disk, it is ignored in privacy mode. Give it in the environment or with --env-file=- instead."
+        fi
+        printf -v "$var" '%s' "${!session_var}"
+    done
+
+    # OpenAI is the only provider of the compute node that needs a key
+    local providers=$(echo ",$DKN_SYNTHESIS_MODEL_PROVIDER,$AGENT_MODEL_PROVIDER," | tr -d '[:space:]' | tr '[:upper:]' '[:lower:]')
+    if [ -z "$OPENAI_API_KEY" ] && [[ "$providers" == *",openai,"* ]]; then
+        if [ -t 0 ]; then
+            read -r -s -p "OpenAI API key (kept for this session only): " OPENAI_API_KEY
+            echo "" >&2
+        else
+            log_warn "OPENAI_API_KEY is not given, OpenAI tasks will fail."
+        fi
+    fi
+
+    # exported even if empty, so that docker-compose does not read them from elsewhere
+    export "${OPTIONAL_KEY_VARS[@]}"
+}
+handle_privacy_keys
+
+# prints the resolved configuration to stdout as an env file or JSON, and exits without starting the node
+# secrets such as the wallet key, the RLN credentials, the Ethereum client address and the API keys are left out,
+# so the output can be shared and imported elsewhere with --env-file=-
+export_config() {
+    local export_vars=(
+        "DKN_TASKS"
+        "DKN_ADMIN_ENV"
+        "$ADMIN_PUBLIC_KEY_VAR"
+        "DKN_SYNTHESIS_MODEL_PROVIDER"
+        "DKN_SYNTHESIS_MODEL_NAME"
+        "AGENT_MODEL_PROVIDER"
+        "AGENT_MODEL_NAME"
+        "OPENAI_TIMEOUT"
+        "DKN_LOG_LEVEL"
+        "DKN_MAX_BATCH_SIZE"
+        "DKN_HEARTBEAT_INTERVAL"
+        "WAKU_EXTRA_ARGS"
+        "WAKU_LOG_LEVEL"
+        "OLLAMA_KEEP_ALIVE"
+        "OLLAMA_TIMEOUT"
+        "OLLAMA_WARM_UP"
+    )
+
+    local separator=""
+    if [ "$EXPORT_FORMAT" == "json" ]; then
+        printf "{"
+    fi
+    for var in "${export_vars[@]}"; do
+        local value="${!var}"
+        if [ -z "$value" ]; then
+            continue
+        fi
+        if [ "$EXPORT_FORMAT" == "json" ]; then
+            value=$(printf "%s" "$value" | sed 's/\\/\\\\/g; s/"/\\"/g')
+            printf '%s\n  "%s": "%s"' "$separator" "$var" "$value"
+            separator=","
+        else
+            printf '%s=%q\n' "$var" "$value"
+        fi
+    done
+    if [ "$EXPORT_FORMAT" == "json" ]; then
+        printf "\n}\n"
+    fi
+}
+if [ -n "$EXPORT_FORMAT" ]; then
+    if [ "$EXPORT_FORMAT" != "env" ] && [ "$EXPORT_FORMAT" != "json" ]; then
+        log_error "Unknown export format: $EXPORT_FORMAT, expected env or json"
+        exit 1
+    fi
+    export_config
+    exit 0
+fi
+
 # this function handles all waku related environment, waku_envs is a list of "name=value" env-var pairs
 waku_envs=()
 handle_waku_env() {
@@ -231,13 +678,13 @@ handle_waku_env() {
     )
     # default value for waku url
     if [[ -z "$WAKU_URL" ]]; then
-        WAKU_URL="http://host.docker.internal:8645"
+        WAKU_URL="${DOCKER_HOST}:8645"
     fi
     waku_envs=($(as_pairs "${waku_env_vars[@]}"))
 
     # add waku profile depending on EXTERNAL_WAKU flag
     if [ "$EXTERNAL_WAKU" == true ]; then
-        echo "External waku is true, not running the waku"
+        log_info "External waku is true, not running the waku"
         return
     else
         COMPOSE_PROFILES+=("waku")
@@ -252,7 +699,7 @@ handle_waku_env() {
         response=$(curl -s -X GET "$WAKU_PEER_DISCOVERY_URL" -d "param1=value1")
         parsed_response=$(echo "$response" | jq -r '.[]')
         if [[ -z "$parsed_response" ]]; then
-            echo "No static peer set for waku"
+            log_debug "No static peer set for waku"
         else
             waku_peers=""
             for peer in ${parsed_response[@]}; do
@@ -273,19 +720,36 @@ handle_waku_env() {
 }
 handle_waku_env
 
+# returns whether an Ollama server answers at the given url, other HTTP servers on the same port do not count
+is_ollama_running() {
+    [ "$(curl -s -m 5 "$1")" == "Ollama is running" ]
+}
+
+# returns whether the given port on the host is taken by any server
+is_port_in_use() {
+    (exec 3<> "/dev/tcp/127.0.0.1/$1") 2> /dev/null
+}
+
 # this function handles all ollama related environment, ollama_envs is a list of "name=value" env-var pairs
 ollama_envs=()
 handle_ollama_env() {
+    # --local-ollama=false is respected, an Ollama server on the host is not used then
+    local host_ollama_allowed="$LOCAL_OLLAMA"
+
     ollama_env_vars=(
         "OLLAMA_HOST"
         "OLLAMA_PORT"
         "OLLAMA_KEEP_ALIVE"
+        "OLLAMA_TIMEOUT"
+        "OLLAMA_WARM_UP"
     )
     ollama_envs=($(as_pairs "${ollama_env_vars[@]}"))
 
     # if there is no task using ollama, do not add any ollama compose profile
     ollama_needed=false
-    if [ "$COMPUTE_SYNTHESIS" = true ] && [ "$DKN_SYNTHESIS_MODEL_PROVIDER" == "ollama" ]; then
+    # synthesis model provider can be a comma-separated list, e.g. "ollama, openai", matched like the node does
+    synthesis_providers=$(echo "$DKN_SYNTHESIS_MODEL_PROVIDER" | tr -d '[:space:]' | tr '[:upper:]' '[:lower:]')
+    if [ "$COMPUTE_SYNTHESIS" = true ] && [[ ",$synthesis_providers," == *",ollama,"* ]]; then
         ollama_needed=true
     fi
     if [ "$COMPUTE_SEARCH" = true ] && [ "$AGENT_MODEL_PROVIDER" == "ollama" ]; then
@@ -295,8 +759,24 @@ handle_ollama_env() {
         return
     fi
 
+    # our own Ollama container from a previous start answers on the host port as well, so it must not be taken for a local Ollama
+    # it keeps running with the port it is published on
+    ollama_container_running=false
+    for service in ollama ollama-rocm ollama-cuda; do
+        local container_id=$(docker ps -q \
+            --filter "label=com.docker.compose.project=${DKN_COMPOSE_PROJECT_NAME}" \
+            --filter "label=com.docker.compose.service=$service" \
+            --filter "label=${DKN_COMPOSE_LABEL}")
+        if [ -n "$container_id" ]; then
+            ollama_container_running=true
+            local published_port=$(docker port "$container_id" 11434 | head -n 1 | sed 's/.*://')
+            OLLAMA_PORT="${published_port:-${OLLAMA_PORT:-11434}}"
+            log_info "Ollama container is already running on port $OLLAMA_PORT, using it"
+        fi
+    done
+
     # check local ollama
-    if [ "$LOCAL_OLLAMA" == true ]; then
+    if [ "$LOCAL_OLLAMA" == true ] && [ "$ollama_container_running" == false ]; then
         if command -v ollama &> /dev/null; then
             # prepare local ollama url
             OLLAMA_HOST="${OLLAMA_HOST:-http://localhost}"
@@ -312,12 +792,12 @@ handle_ollama_env() {
             }
 
             if [[ "$(check_ollama_server)" -eq 200 ]]; then
-                echo "Local Ollama is already up and running, using it"
+                log_info "Local Ollama is already up and running, using it"
                 OLLAMA_HOST=$DOCKER_HOST
                 ollama_envs=($(as_pairs "${ollama_env_vars[@]}"))
                 return
             else
-                echo "Local Ollama is not live, running ollama serve"
+                log_info "Local Ollama is not live, running ollama serve"
                 temp_ollama_host=$OLLAMA_HOST
                 OLLAMA_HOST=$ollama_url # set temporarily OLLAMA_HOST env var for the ollama command
                 # run ollama serve in background
@@ -328,33 +808,58 @@ handle_ollama_env() {
                 RETRY_COUNT=0
                 # Loop until the server responds with HTTP 200 or the retry limit is reached
                 until [ "$(check_ollama_server)" -eq 200 ] || [ "$RETRY_COUNT" -ge "$MAX_RETRIES" ]; do
-                    echo "Waiting for the local ollama server to start... (Attempt $((RETRY_COUNT + 1))/$MAX_RETRIES)"
+                    log_info "Waiting for the local ollama server to start... (Attempt $((RETRY_COUNT + 1))/$MAX_RETRIES)"
                     sleep 1
                     RETRY_COUNT=$((RETRY_COUNT + 1))
                 done
 
                 if [ "$RETRY_COUNT" -ge "$MAX_RETRIES" ]; then
-                    echo "Local ollama server failed to start after $MAX_RETRIES attempts."
-                    echo "Using docker-compose service"
+                    log_warn "Local ollama server failed to start after $MAX_RETRIES attempts, using docker-compose service"
+                    # do not leave the server behind, in case it comes up later and takes the port
+                    kill "$temp_pid" 2> /dev/null
+                    OLLAMA_HOST=$temp_ollama_host
                     LOCAL_OLLAMA=false
                 else
                     LOCAL_OLLAMA_PID=$temp_pid
                     OLLAMA_HOST=$DOCKER_HOST
-                    echo "Local Ollama server is up and running with PID $LOCAL_OLLAMA_PID"
+                    log_info "Local Ollama server is up and running with PID $LOCAL_OLLAMA_PID"
                     ollama_envs=($(as_pairs "${ollama_env_vars[@]}"))
                     return
                 fi
             fi
         else
             LOCAL_OLLAMA=false
-            echo "Ollama is not installed on this machine, using the docker-compose service"
+            log_info "Ollama is not installed on this machine, using the docker-compose service"
         fi
     fi
 
+    # docker-compose Ollama publishes port OLLAMA_PORT (default: 11434), so an Ollama server on the host that already uses it is reused instead,
+    # unless --local-ollama=false is given, in which case the container is published on the next free port
+    # if our own Ollama container is already running, the port is expected to be in use, and it keeps its port
+    OLLAMA_PORT="${OLLAMA_PORT:-11434}"
+    if [ "$ollama_container_running" == false ] && is_port_in_use "$OLLAMA_PORT"; then
+        if [ "$host_ollama_allowed" == true ] && is_ollama_running "http://localhost:$OLLAMA_PORT"; then
+            log_warn "Port $OLLAMA_PORT is already in use by an Ollama server on the host, using it instead of the docker-compose service"
+            OLLAMA_HOST=$DOCKER_HOST
+            ollama_envs=($(as_pairs "${ollama_env_vars[@]}"))
+            export OLLAMA_PORT
+            return
+        fi
+
+        local taken_port=$OLLAMA_PORT
+        while is_port_in_use "$OLLAMA_PORT"; do
+            OLLAMA_PORT=$((OLLAMA_PORT + 1))
+        done
+        log_warn "Port $taken_port is already in use on the host, publishing the docker-compose Ollama on port $OLLAMA_PORT instead"
+    fi
+    # exported for docker-compose, which publishes the Ollama container and points the compute node at this port
+    export OLLAMA_PORT
+    ollama_envs=($(as_pairs "${ollama_env_vars[@]}"))
+
     # check for cuda gpu
     if command -v nvidia-smi &> /dev/null; then
         if nvidia-smi &> /dev/null; then
-            echo "GPU type detected: CUDA"
+            log_info "GPU type detected: CUDA"
             COMPOSE_PROFILES+=("ollama-cuda")
             return
         fi
@@ -363,22 +868,56 @@ handle_ollama_env() {
     # check for rocm gpu
     if command -v rocminfo &> /dev/null; then
         if rocminfo &> /dev/null; then
-            echo "GPU type detected: ROCM"
+            log_info "GPU type detected: ROCM"
             COMPOSE_PROFILES+=("ollama-rocm")
             return
         fi
     fi
 
     # if there are no local ollama and gpu, use docker-compose with cpu profile
-    echo "No GPU found, using ollama-cpu"
+    log_info "No GPU found, using ollama-cpu"
     COMPOSE_PROFILES+=("ollama-cpu")
     OLLAMA_HOST=$DOCKER_HOST
     ollama_envs=($(as_pairs "${ollama_env_vars[@]}"))
 }
 handle_ollama_env
 
+# derive the batch size from the hardware when a local Ollama model is in use and the size is not set, or when it is
+# set to auto: one task per 4 GB of GPU memory with an NVIDIA GPU, otherwise one task per 2 CPU cores
+# otherwise an unset batch size means no limit, e.g. the hardware has nothing to do with the throughput of OpenAI
+handle_batch_size() {
+    if [ "$DKN_MAX_BATCH_SIZE" == "auto" ]; then
+        DKN_MAX_BATCH_SIZE=""
+    elif [ -n "$DKN_MAX_BATCH_SIZE" ] || [ "$ollama_needed" != true ]; then
+        return
+    fi
+
+    if command -v nvidia-smi &> /dev/null; then
+        gpu_memory_mb=$(nvidia-smi --query-gpu=memory.total --format=csv,noheader,nounits 2> /dev/null | head -n 1 | tr -d ' ')
+    fi
+    if [[ "$gpu_memory_mb" =~ ^[0-9]+$ ]]; then
+        DKN_MAX_BATCH_SIZE=$((gpu_memory_mb / 4096))
+    else
+        cpu_cores=$(nproc 2> /dev/null || sysctl -n hw.ncpu 2> /dev/null)
+        if [[ "$cpu_cores" =~ ^[0-9]+$ ]]; then
+            DKN_MAX_BATCH_SIZE=$((cpu_cores / 2))
+        fi
+    fi
+
+    # at least one task per batch, 0 would mean no limit
+    if [ -z "$DKN_MAX_BATCH_SIZE" ] || [ "$DKN_MAX_BATCH_SIZE" -lt 1 ]; then
+        DKN_MAX_BATCH_SIZE=1
+    fi
+    log_info "Max batch size is set to $DKN_MAX_BATCH_SIZE based on your hardware."
+    compute_envs=($(as_pairs "${compute_env_vars[@]}"))
+}
+handle_batch_size
+
 # env-var lists are ready, now write them to .env.compose
+# the previous one is kept to tell whether the configuration of a running node has changed
+PREVIOUS_ENV_COMPOSE=""
 if [ -e "$ENV_COMPOSE_FILE" ]; then
+    PREVIOUS_ENV_COMPOSE=$(cat "$ENV_COMPOSE_FILE")
     # if already exists, clean it first
     rm "$ENV_COMPOSE_FILE"
 fi
@@ -386,36 +925,214 @@ write_to_env_file "${waku_envs[@]}"
 write_to_env_file "${compute_envs[@]}"
 write_to_env_file "${ollama_envs[@]}"
 
+# compute node runs within docker-compose unless it is run from source
+if [ "$FROM_SOURCE" == false ]; then
+    COMPOSE_PROFILES+=("compute")
+fi
+
 # prepare compose profiles
 COMPOSE_PROFILES=$(IFS=","; echo "${COMPOSE_PROFILES[*]}")
+HOOK_COMPOSE_PROFILES="$COMPOSE_PROFILES"
 COMPOSE_PROFILES="COMPOSE_PROFILES=\"${COMPOSE_PROFILES}\""
 
+# profiles are recorded as a comment, so that a change of profiles counts as a configuration change as well
+echo "# COMPOSE_PROFILES=${HOOK_COMPOSE_PROFILES}" >> "$ENV_COMPOSE_FILE"
+
+# make sure the compose project is not used by containers that were not created by this script
+check_compose_project() {
+    foreign_containers=0
+    while IFS='|' read -r dkn_label working_dir; do
+        # containers labeled by this script are ours, and so are the unlabeled ones
+        # created from this directory by older versions of this script
+        if [ "$dkn_label" == "${DKN_COMPOSE_LABEL#*=}" ] || [ "$working_dir" == "$PWD" ]; then
+            continue
+        fi
+        foreign_containers=$((foreign_containers + 1))
+    done < <(docker ps -a \
+        --filter "label=com.docker.compose.project=${DKN_COMPOSE_PROJECT_NAME}" \
+        --format '{{.Label "'"${DKN_COMPOSE_LABEL%%=*}"'"}}|{{.Label "com.docker.compose.project.working_dir"}}')
+
+    if [ "$foreign_containers" -ne 0 ]; then
+        log_error "docker-compose project \"${DKN_COMPOSE_PROJECT_NAME}\" is used by another application." \
+            "If its containers belong to an older setup of this node, remove them first with:" \
+            "    ${DOCKER_COMPOSE} -p ${DKN_COMPOSE_PROJECT_NAME} down" \
+            "Otherwise, set DKN_COMPOSE_PROJECT_NAME to a different name and try again."
+        exit 1
+    fi
+}
+check_compose_project
+
+# find the node containers left in other compose projects, e.g. from a checkout in a directory with another name
+# these are found by our label, or by this directory for the unlabeled ones created by older versions of this script
+check_other_projects() {
+    local other_projects
+    other_projects=$( {
+        docker ps -a --filter "label=${DKN_COMPOSE_LABEL}" --format '{{.Label "com.docker.compose.project"}}'
+        docker ps -a --filter "label=com.docker.compose.project.working_dir=${PWD}" --format '{{.Label "com.docker.compose.project"}}'
+    } | sort -u | grep -vxF -e "${DKN_COMPOSE_PROJECT_NAME}" -e "")
+
+    if [ -n "$other_projects" ]; then
+        local down_commands=()
+        for project in $other_projects; do
+            down_commands+=("    ${DOCKER_COMPOSE} -p ${project} down")
+        done
+        log_error "Compute node containers exist in other docker-compose projects: $(echo $other_projects)" \
+            "They would run alongside this node with the same wallet and ports, remove them first with:" \
+            "${down_commands[@]}"
+        exit 1
+    fi
+}
+check_other_projects
+
+# starting again while running is fine, docker-compose only recreates the services whose configuration has changed
+# the node stays in the background then, so that exiting this run does not stop the node started by the earlier one
+CONFIG_UPDATE=false
+if [ "$FROM_SOURCE" == false ] && is_service_running "compute"; then
+    if [ "$(cat "$ENV_COMPOSE_FILE")" == "$PREVIOUS_ENV_COMPOSE" ]; then
+        log_info "Compute node is already running."
+    else
+        log_info "Compute node is already running, applying the changed configuration."
+        CONFIG_UPDATE=true
+    fi
+    START_MODE="BACKGROUND"
+fi
+
 # prepare compose commands
-COMPOSE_COMMAND="docker-compose"
+COMPOSE_COMMAND="${DOCKER_COMPOSE} -p ${DKN_COMPOSE_PROJECT_NAME}"
+if [ "$PRIVACY_MODE" == true ]; then
+    COMPOSE_COMMAND="${COMPOSE_COMMAND} -f compose.yml -f compose.privacy.yml"
+fi
 COMPOSE_UP="${COMPOSE_PROFILES} ${COMPOSE_COMMAND} up -d"
 COMPOSE_DOWN="${COMPOSE_PROFILES} ${COMPOSE_COMMAND} down"
 
+# run pre-update hook when the running node is about to be recreated with a changed configuration
+if [ "$CONFIG_UPDATE" == true ]; then
+    run_hook "DKN_HOOK_PRE_UPDATE" "pre-update"
+    hook_exit_code=$?
+    if [ $hook_exit_code -ne 0 ]; then
+        log_error "Pre-update hook failed with exit code $hook_exit_code"
+        exit $hook_exit_code
+    fi
+fi
+
+# run pre-start hook
+run_hook "DKN_HOOK_PRE_START" "pre-start"
+hook_exit_code=$?
+if [ $hook_exit_code -ne 0 ]; then
+    log_error "Pre-start hook failed with exit code $hook_exit_code"
+    exit $hook_exit_code
+fi
+
+# the compute container from an earlier start must not run alongside the node from source, with the same wallet
+if [ "$FROM_SOURCE" == true ] && is_service_running "compute"; then
+    log_info "Stopping the compute container to run the compute node from source"
+    eval "${COMPOSE_COMMAND} rm -s -f compute"
+fi
+
 # run docker-compose up
-echo "Starting in ${START_MODE} mode...\n"
-echo "${COMPOSE_UP}\n"
+log_info "Starting in ${START_MODE} mode..."
+log_debug "${COMPOSE_UP}"
 eval "${COMPOSE_UP}"
 compose_exit_code=$?
 
 # handle docker-compose error
 if [ $compose_exit_code -ne 0 ]; then
-    echo "\nERROR: docker-compose"
+    log_error "docker-compose failed with exit code $compose_exit_code"
     exit $compose_exit_code
 fi
 
+# run post-start hook, its failure does not stop the node
+run_hook "DKN_HOOK_POST_START" "post-start" "$compose_exit_code" || log_warn "Post-start hook failed"
+
+# returns the modification time of the given file, on both Linux and macOS
+modified_at() {
+    stat -c %Y "$1" 2> /dev/null || stat -f %m "$1" 2> /dev/null
+}
+
+# builds and runs the compute node natively, and restarts it whenever its binary is rebuilt
+# returns the exit code of the node if it exits by itself, e.g. on a crash, and the exit code of cargo if the build fails
+run_from_source() {
+    cargo build --release --manifest-path "${SOURCE_PATH}/Cargo.toml" || return
+    local binary="${CARGO_TARGET_DIR:-${SOURCE_PATH}/target}/release/dkn-compute"
+
+    local stopping=false
+    local node_pid=""
+    trap 'stopping=true; [ -n "$node_pid" ] && kill -INT "$node_pid" 2> /dev/null' SIGINT
+
+    while true; do
+        local built_at=$(modified_at "$binary")
+        "$binary" &
+        node_pid=$!
+
+        local rebuilt=false
+        while kill -0 "$node_pid" 2> /dev/null; do
+            sleep 2
+            if [ "$stopping" == false ] && [ "$(modified_at "$binary")" != "$built_at" ]; then
+                log_info "Compute node is rebuilt, restarting..."
+                rebuilt=true
+                kill -INT "$node_pid"
+                break
+            fi
+        done
+        # wait is interrupted by SIGINT, so keep waiting until the node shuts down gracefully
+        local node_exit_code=0
+        wait "$node_pid"
+        node_exit_code=$?
+        while kill -0 "$node_pid" 2> /dev/null; do
+            wait "$node_pid"
+            node_exit_code=$?
+        done
+
+        if [ "$stopping" == true ]; then
+            return 0
+        fi
+        if [ "$rebuilt" == false ]; then
+            log_error "Compute node exited with code $node_exit_code"
+            if [ $node_exit_code -ne 0 ]; then
+                run_hook "DKN_HOOK_ON_CRASH" "on-crash" "$node_exit_code" || log_warn "On-crash hook failed"
+            fi
+            return $node_exit_code
+        fi
+    done
+}
+
+# run the compute node from source, and stop the services when it exits
+if [ "$FROM_SOURCE" == true ]; then
+    log_info "Running compute node from source, use Control-C to exit"
+
+    # handle SIGINT here as well, so that the script continues with the cleanup
+    trap "true" SIGINT
+    (
+        set -o allexport
+        source "$ENV_COMPOSE_FILE"
+        # the node runs on the host, so it reaches the services via localhost instead of the docker host,
+        # and it needs the variables that compose.yml sets for the compute container
+        WAKU_URL="${WAKU_URL/$DOCKER_HOST/http://localhost}"
+        OLLAMA_HOST="${OLLAMA_HOST/$DOCKER_HOST/http://localhost}"
+        SEARCH_AGENT_URL="http://localhost:5059"
+        SEARCH_AGENT_MANAGER=true
+        RUST_LOG="${DKN_LOG_LEVEL:-info}"
+        set +o allexport
+        run_from_source
+    )
+    from_source_exit_code=$?
+
+    log_info "Shutting down..."
+    eval "${COMPOSE_DOWN}"
+    rm "$ENV_COMPOSE_FILE"
+    log_info "bye"
+    exit $from_source_exit_code
+fi
+
 # background/foreground mode
 if [ "$START_MODE" == "FOREGROUND" ]; then
-    echo "\nUse Control-C to exit"
+    log_info "Use Control-C to exit"
 
     cleanup() {
-        echo "\nShutting down..."
+        log_info "Shutting down..."
         eval "${COMPOSE_DOWN}"
         rm "$ENV_COMPOSE_FILE"
-        echo "\nbye"
+        log_info "bye"
         exit
     }
     # wait for Ctrl-C